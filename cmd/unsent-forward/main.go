@@ -0,0 +1,72 @@
+// Command unsent-forward relays Unsent webhook events to a local URL during
+// development. With -tunnel it registers a temporary webhook pointing at a
+// public tunnel (ngrok, cloudflared, ...) that reaches -listen; without it,
+// it polls the events API. The temporary webhook is removed on exit.
+//
+//	UNSENT_API_KEY=un_xxx unsent-forward -to http://localhost:3000/webhooks -tunnel https://abc.ngrok.app
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/souravsspace/unsent-go/pkg/unsent"
+)
+
+func main() {
+	to := flag.String("to", "", "local URL that receives the events (required)")
+	tunnel := flag.String("tunnel", "", "public tunnel URL that reaches -listen; polls the events API when empty")
+	listen := flag.String("listen", "127.0.0.1:8787", "address the tunnel forwards to")
+	events := flag.String("events", "", "comma separated event types, defaults to all")
+	secret := flag.String("secret", os.Getenv("UNSENT_WEBHOOK_SECRET"), "secret used to sign forwarded requests")
+	interval := flag.Duration("interval", 2*time.Second, "events API poll interval")
+	flag.Parse()
+
+	if *to == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	client, err := unsent.NewClient("")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var eventTypes []string
+	if *events != "" {
+		eventTypes = strings.Split(*events, ",")
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if *tunnel != "" {
+		log.Printf("forwarding %s -> %s via %s", *tunnel, *to, *listen)
+	} else {
+		log.Printf("polling events every %s -> %s", *interval, *to)
+	}
+	err = unsent.ForwardWebhooks(ctx, client, unsent.WebhookForwardOptions{
+		TargetURL:   *to,
+		TunnelURL:   *tunnel,
+		ListenAddr:  *listen,
+		EventTypes:  eventTypes,
+		Secret:      *secret,
+		PollOptions: unsent.PollOptions{Interval: *interval, MaxInterval: *interval},
+		OnForward: func(f unsent.ForwardedWebhook) {
+			if f.Err != nil {
+				log.Printf("%s %s: %v", f.EventType, f.EventID, f.Err)
+				return
+			}
+			log.Printf("%s %s: %d in %s", f.EventType, f.EventID, f.StatusCode, f.Duration.Round(time.Millisecond))
+		},
+	})
+	// a plain cancellation is a normal exit; anything joined to it, such as a
+	// failed webhook cleanup, is still reported
+	if err != nil && err != context.Canceled {
+		log.Fatal(err)
+	}
+}
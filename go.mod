@@ -2,6 +2,9 @@ module github.com/souravsspace/unsent-go
 
 go 1.25.5
 
-require github.com/oapi-codegen/runtime v1.1.2
+require (
+	github.com/oapi-codegen/runtime v1.1.2
+	gopkg.in/yaml.v3 v3.0.1
+)
 
 require github.com/google/uuid v1.5.0 // indirect
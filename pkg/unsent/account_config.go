@@ -0,0 +1,590 @@
+package unsent
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// AccountSpec declares the desired configuration of a team. Resources are
+// matched to the live account by their natural key: domain name, webhook
+// URL, template name, contact book name and API key name.
+type AccountSpec struct {
+	Domains      []DomainSpec      `json:"domains,omitempty" yaml:"domains,omitempty"`
+	Webhooks     []WebhookSpec     `json:"webhooks,omitempty" yaml:"webhooks,omitempty"`
+	Templates    []TemplateSpec    `json:"templates,omitempty" yaml:"templates,omitempty"`
+	ContactBooks []ContactBookSpec `json:"contactBooks,omitempty" yaml:"contactBooks,omitempty"`
+	ApiKeys      []ApiKeySpec      `json:"apiKeys,omitempty" yaml:"apiKeys,omitempty"`
+	// Prune deletes live resources that are not declared in the spec
+	Prune bool `json:"prune,omitempty" yaml:"prune,omitempty"`
+}
+
+// DomainSpec declares a sending domain. The domains API does not return a
+// domain's region, so Region is only used when the domain is created and a
+// region change to an existing domain is not planned.
+type DomainSpec struct {
+	Name   string `json:"name" yaml:"name"`
+	Region string `json:"region" yaml:"region"`
+}
+
+// WebhookSpec declares a webhook endpoint
+type WebhookSpec struct {
+	Url         string   `json:"url" yaml:"url"`
+	EventTypes  []string `json:"eventTypes" yaml:"eventTypes"`
+	Active      *bool    `json:"active,omitempty" yaml:"active,omitempty"`
+	Description *string  `json:"description,omitempty" yaml:"description,omitempty"`
+}
+
+// TemplateSpec declares an email template
+type TemplateSpec struct {
+	Name    string  `json:"name" yaml:"name"`
+	Subject string  `json:"subject" yaml:"subject"`
+	Html    *string `json:"html,omitempty" yaml:"html,omitempty"`
+	Content *string `json:"content,omitempty" yaml:"content,omitempty"`
+}
+
+// ContactBookSpec declares a contact book and its property schema
+type ContactBookSpec struct {
+	Name       string            `json:"name" yaml:"name"`
+	Emoji      string            `json:"emoji,omitempty" yaml:"emoji,omitempty"`
+	Properties map[string]string `json:"properties,omitempty" yaml:"properties,omitempty"`
+}
+
+// ApiKeySpec declares an API key. Keys cannot be updated, so a permission
+// change replaces the key: the new key is created before the old one is
+// deleted. The key the applying client uses is never deleted.
+type ApiKeySpec struct {
+	Name       string `json:"name" yaml:"name"`
+	Permission string `json:"permission,omitempty" yaml:"permission,omitempty"`
+}
+
+// webhookEventTypes lists the event types accepted by the webhooks API
+var webhookEventTypes = []CreateWebhookJSONBodyEventTypes{
+	CreateWebhookJSONBodyEventTypesContactCreated,
+	CreateWebhookJSONBodyEventTypesContactDeleted,
+	CreateWebhookJSONBodyEventTypesContactUpdated,
+	CreateWebhookJSONBodyEventTypesDomainCreated,
+	CreateWebhookJSONBodyEventTypesDomainDeleted,
+	CreateWebhookJSONBodyEventTypesDomainUpdated,
+	CreateWebhookJSONBodyEventTypesDomainVerified,
+	CreateWebhookJSONBodyEventTypesEmailBounced,
+	CreateWebhookJSONBodyEventTypesEmailCancelled,
+	CreateWebhookJSONBodyEventTypesEmailClicked,
+	CreateWebhookJSONBodyEventTypesEmailComplained,
+	CreateWebhookJSONBodyEventTypesEmailDelivered,
+	CreateWebhookJSONBodyEventTypesEmailDeliveryDelayed,
+	CreateWebhookJSONBodyEventTypesEmailFailed,
+	CreateWebhookJSONBodyEventTypesEmailOpened,
+	CreateWebhookJSONBodyEventTypesEmailQueued,
+	CreateWebhookJSONBodyEventTypesEmailRejected,
+	CreateWebhookJSONBodyEventTypesEmailRenderingFailure,
+	CreateWebhookJSONBodyEventTypesEmailSent,
+	CreateWebhookJSONBodyEventTypesEmailSuppressed,
+}
+
+func isWebhookEventType(eventType string) bool {
+	for _, known := range webhookEventTypes {
+		if string(known) == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadAccountSpec reads a YAML or JSON account spec and validates it.
+// Unknown keys are rejected so a misspelled field is not silently ignored.
+func LoadAccountSpec(r io.Reader) (*AccountSpec, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var spec AccountSpec
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&spec); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("parsing account spec: %w", err)
+	}
+	if err := spec.Validate(); err != nil {
+		return nil, err
+	}
+	return &spec, nil
+}
+
+// Validate checks required fields, duplicate keys and webhook event types
+func (s *AccountSpec) Validate() error {
+	var errs []error
+	seen := make(map[string]bool)
+	unique := func(kind, key string) {
+		if key == "" {
+			errs = append(errs, fmt.Errorf("%s is missing its %s", kind, specKeyName(kind)))
+			return
+		}
+		if seen[kind+"\x00"+key] {
+			errs = append(errs, fmt.Errorf("%s %q is declared more than once", kind, key))
+		}
+		seen[kind+"\x00"+key] = true
+	}
+
+	for _, d := range s.Domains {
+		unique("domain", d.Name)
+		if d.Region == "" {
+			errs = append(errs, fmt.Errorf("domain %q is missing its region", d.Name))
+		}
+	}
+	for _, w := range s.Webhooks {
+		unique("webhook", w.Url)
+		if len(w.EventTypes) == 0 {
+			errs = append(errs, fmt.Errorf("webhook %q has no event types", w.Url))
+		}
+		for _, eventType := range w.EventTypes {
+			if !isWebhookEventType(eventType) {
+				errs = append(errs, fmt.Errorf("webhook %q has unknown event type %q", w.Url, eventType))
+			}
+		}
+	}
+	for _, t := range s.Templates {
+		unique("template", t.Name)
+		if t.Subject == "" {
+			errs = append(errs, fmt.Errorf("template %q is missing its subject", t.Name))
+		}
+	}
+	for _, b := range s.ContactBooks {
+		unique("contact book", b.Name)
+	}
+	for _, k := range s.ApiKeys {
+		unique("api key", k.Name)
+		if k.Permission != "" && k.Permission != string(FULL) && k.Permission != string(SENDING) {
+			errs = append(errs, fmt.Errorf("api key %q has unknown permission %q", k.Name, k.Permission))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func specKeyName(kind string) string {
+	if kind == "webhook" {
+		return "url"
+	}
+	return "name"
+}
+
+// PlanAction is the kind of change a plan makes to a resource
+type PlanAction string
+
+const (
+	PlanCreate  PlanAction = "create"
+	PlanUpdate  PlanAction = "update"
+	PlanReplace PlanAction = "replace"
+	PlanDelete  PlanAction = "delete"
+)
+
+// FieldChange describes a single attribute that differs from the spec
+type FieldChange struct {
+	Field string
+	From  string
+	To    string
+}
+
+// PlanChange is a single change to a live resource
+type PlanChange struct {
+	Action   PlanAction
+	Resource string
+	Key      string
+	// ID of the live resource for updates, replacements and deletions
+	ID      string
+	Changes []FieldChange
+
+	desired interface{}
+}
+
+// AccountPlan is the ordered list of changes that make an account match a spec
+type AccountPlan struct {
+	Changes []PlanChange
+}
+
+// Empty reports whether the account already matches the spec
+func (p *AccountPlan) Empty() bool {
+	return len(p.Changes) == 0
+}
+
+// String renders the plan in a Terraform-like format
+func (p *AccountPlan) String() string {
+	if p.Empty() {
+		return "No changes. The account matches the spec.\n"
+	}
+	counts := make(map[PlanAction]int)
+	var b strings.Builder
+	for _, change := range p.Changes {
+		counts[change.Action]++
+		symbol := map[PlanAction]string{PlanCreate: "+", PlanUpdate: "~", PlanReplace: "-/+", PlanDelete: "-"}[change.Action]
+		fmt.Fprintf(&b, "  %s %s %q\n", symbol, change.Resource, change.Key)
+		for _, field := range change.Changes {
+			fmt.Fprintf(&b, "      %s: %s -> %s\n", field.Field, field.From, field.To)
+		}
+	}
+	return fmt.Sprintf("Plan: %d to create, %d to update, %d to replace, %d to delete.\n\n%s",
+		counts[PlanCreate], counts[PlanUpdate], counts[PlanReplace], counts[PlanDelete], b.String())
+}
+
+// PlanAccount diffs a spec against the live account using the resource clients
+func PlanAccount(client *Client, spec *AccountSpec) (*AccountPlan, error) {
+	if err := spec.Validate(); err != nil {
+		return nil, err
+	}
+	plan := &AccountPlan{}
+
+	domains, apiErr := client.Domains.List()
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	liveDomains := make(map[string]Domain)
+	for _, d := range *domains {
+		liveDomains[strings.ToLower(d.Domain)] = d
+	}
+	for _, d := range spec.Domains {
+		key := strings.ToLower(d.Name)
+		if _, ok := liveDomains[key]; !ok {
+			plan.add(PlanChange{Action: PlanCreate, Resource: "domain", Key: d.Name, desired: d,
+				Changes: []FieldChange{{"region", "", d.Region}}})
+		}
+		delete(liveDomains, key)
+	}
+	if spec.Prune {
+		for _, d := range liveDomains {
+			plan.add(PlanChange{Action: PlanDelete, Resource: "domain", Key: d.Domain, ID: d.ID})
+		}
+	}
+
+	webhooks, apiErr := client.Webhooks.List()
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	liveWebhooks := make(map[string]Webhook)
+	for _, w := range *webhooks {
+		liveWebhooks[w.Url] = w
+	}
+	for _, w := range spec.Webhooks {
+		live, ok := liveWebhooks[w.Url]
+		if !ok {
+			plan.add(PlanChange{Action: PlanCreate, Resource: "webhook", Key: w.Url, desired: w,
+				Changes: []FieldChange{{"eventTypes", "", strings.Join(w.EventTypes, ",")}}})
+		} else if changes := webhookChanges(live, w); len(changes) > 0 {
+			plan.add(PlanChange{Action: PlanUpdate, Resource: "webhook", Key: w.Url, ID: live.ID, Changes: changes, desired: w})
+		}
+		delete(liveWebhooks, w.Url)
+	}
+	if spec.Prune {
+		for _, w := range liveWebhooks {
+			plan.add(PlanChange{Action: PlanDelete, Resource: "webhook", Key: w.Url, ID: w.ID})
+		}
+	}
+
+	templates, apiErr := client.Templates.List()
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	liveTemplates := make(map[string]Template)
+	for _, t := range *templates {
+		liveTemplates[t.Name] = t
+	}
+	for _, t := range spec.Templates {
+		live, ok := liveTemplates[t.Name]
+		if !ok {
+			plan.add(PlanChange{Action: PlanCreate, Resource: "template", Key: t.Name, desired: t,
+				Changes: []FieldChange{{"subject", "", t.Subject}}})
+		} else if changes := templateChanges(live, t); len(changes) > 0 {
+			plan.add(PlanChange{Action: PlanUpdate, Resource: "template", Key: t.Name, ID: live.ID, Changes: changes, desired: t})
+		}
+		delete(liveTemplates, t.Name)
+	}
+	if spec.Prune {
+		for _, t := range liveTemplates {
+			plan.add(PlanChange{Action: PlanDelete, Resource: "template", Key: t.Name, ID: t.ID})
+		}
+	}
+
+	books, apiErr := client.ContactBooks.List()
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	liveBooks := make(map[string]ContactBook)
+	for _, b := range *books {
+		liveBooks[b.Name] = b
+	}
+	for _, b := range spec.ContactBooks {
+		live, ok := liveBooks[b.Name]
+		if !ok {
+			plan.add(PlanChange{Action: PlanCreate, Resource: "contact book", Key: b.Name, desired: b,
+				Changes: []FieldChange{{"properties", "", formatProperties(b.Properties)}}})
+		} else if changes := contactBookChanges(live, b); len(changes) > 0 {
+			plan.add(PlanChange{Action: PlanUpdate, Resource: "contact book", Key: b.Name, ID: live.ID, Changes: changes, desired: b})
+		}
+		delete(liveBooks, b.Name)
+	}
+	if spec.Prune {
+		for _, b := range liveBooks {
+			plan.add(PlanChange{Action: PlanDelete, Resource: "contact book", Key: b.Name, ID: b.ID})
+		}
+	}
+
+	keys, apiErr := client.ApiKeys.List()
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	liveKeys := make(map[string]ApiKey)
+	for _, k := range *keys {
+		liveKeys[k.Name] = k
+	}
+	for _, k := range spec.ApiKeys {
+		permission := k.Permission
+		if permission == "" {
+			permission = string(FULL)
+		}
+		live, ok := liveKeys[k.Name]
+		if !ok {
+			plan.add(PlanChange{Action: PlanCreate, Resource: "api key", Key: k.Name, desired: k,
+				Changes: []FieldChange{{"permission", "", permission}}})
+		} else if live.Permission != permission {
+			plan.add(PlanChange{Action: PlanReplace, Resource: "api key", Key: k.Name, ID: live.ID, desired: k,
+				Changes: []FieldChange{{"permission", live.Permission, permission}}})
+		}
+		delete(liveKeys, k.Name)
+	}
+	if spec.Prune {
+		for _, k := range liveKeys {
+			plan.add(PlanChange{Action: PlanDelete, Resource: "api key", Key: k.Name, ID: k.ID})
+		}
+	}
+
+	return plan, nil
+}
+
+func (p *AccountPlan) add(change PlanChange) {
+	p.Changes = append(p.Changes, change)
+}
+
+func webhookChanges(live Webhook, spec WebhookSpec) []FieldChange {
+	var changes []FieldChange
+	liveTypes := live.EventTypes
+	if len(liveTypes) == 0 {
+		liveTypes = live.Events
+	}
+	if from, to := sortedJoin(liveTypes), sortedJoin(spec.EventTypes); from != to {
+		changes = append(changes, FieldChange{"eventTypes", from, to})
+	}
+	if spec.Active != nil && (live.Active == nil || *live.Active != *spec.Active) {
+		changes = append(changes, FieldChange{"active", formatBoolPtr(live.Active), formatBoolPtr(spec.Active)})
+	}
+	if spec.Description != nil && (live.Description == nil || *live.Description != *spec.Description) {
+		changes = append(changes, FieldChange{"description", formatStringPtr(live.Description), formatStringPtr(spec.Description)})
+	}
+	return changes
+}
+
+func templateChanges(live Template, spec TemplateSpec) []FieldChange {
+	var changes []FieldChange
+	if live.Subject != spec.Subject {
+		changes = append(changes, FieldChange{"subject", fmt.Sprintf("%q", live.Subject), fmt.Sprintf("%q", spec.Subject)})
+	}
+	if spec.Html != nil && live.HTML != *spec.Html {
+		changes = append(changes, FieldChange{"html", fmt.Sprintf("(%d bytes)", len(live.HTML)), fmt.Sprintf("(%d bytes)", len(*spec.Html))})
+	}
+	if spec.Content != nil && live.Content != *spec.Content {
+		changes = append(changes, FieldChange{"content", fmt.Sprintf("(%d bytes)", len(live.Content)), fmt.Sprintf("(%d bytes)", len(*spec.Content))})
+	}
+	return changes
+}
+
+func contactBookChanges(live ContactBook, spec ContactBookSpec) []FieldChange {
+	var changes []FieldChange
+	if spec.Emoji != "" && live.Emoji != spec.Emoji {
+		changes = append(changes, FieldChange{"emoji", live.Emoji, spec.Emoji})
+	}
+	if spec.Properties != nil && !reflect.DeepEqual(live.Properties, spec.Properties) && !(len(live.Properties) == 0 && len(spec.Properties) == 0) {
+		changes = append(changes, FieldChange{"properties", formatProperties(live.Properties), formatProperties(spec.Properties)})
+	}
+	return changes
+}
+
+// AccountApplyOptions configures ApplyAccountPlan
+type AccountApplyOptions struct {
+	// DryRun prints the plan without changing the account
+	DryRun bool
+	// Out receives the rendered plan and per-change progress, may be nil
+	Out io.Writer
+}
+
+// AccountApplyFailure records a change that could not be applied
+type AccountApplyFailure struct {
+	Change PlanChange
+	Err    error
+}
+
+// AccountApplyResult reports the outcome of applying a plan
+type AccountApplyResult struct {
+	Applied  []PlanChange
+	Failures []AccountApplyFailure
+	// ApiKeyTokens holds the tokens of created or replaced API keys by name.
+	// They are only returned once by the API.
+	ApiKeyTokens map[string]string
+}
+
+// ApplyAccountPlan executes a plan in order. Failed changes are recorded and
+// the remaining changes still run; an error is returned if any change failed.
+func ApplyAccountPlan(client *Client, plan *AccountPlan, opts AccountApplyOptions) (*AccountApplyResult, error) {
+	out := opts.Out
+	if out == nil {
+		out = io.Discard
+	}
+	fmt.Fprint(out, plan.String())
+	result := &AccountApplyResult{ApiKeyTokens: make(map[string]string)}
+	if opts.DryRun || plan.Empty() {
+		return result, nil
+	}
+
+	fmt.Fprintln(out)
+	for _, change := range plan.Changes {
+		token, err := applyPlanChange(client, change)
+		// a replaced key's token is kept even when deleting the old key failed
+		if token != "" {
+			result.ApiKeyTokens[change.Key] = token
+		}
+		if err != nil {
+			fmt.Fprintf(out, "%s %s %q: failed: %v\n", change.Action, change.Resource, change.Key, err)
+			result.Failures = append(result.Failures, AccountApplyFailure{Change: change, Err: err})
+			continue
+		}
+		fmt.Fprintf(out, "%s %s %q: done\n", change.Action, change.Resource, change.Key)
+		result.Applied = append(result.Applied, change)
+	}
+	if len(result.Failures) > 0 {
+		return result, fmt.Errorf("%d of %d changes failed", len(result.Failures), len(plan.Changes))
+	}
+	return result, nil
+}
+
+// applyPlanChange performs a single change, returning the token of created API keys
+func applyPlanChange(client *Client, change PlanChange) (string, error) {
+	var err *APIError
+	switch desired := change.desired.(type) {
+	case DomainSpec:
+		_, err = client.Domains.Create(CreateDomainJSONBody{Name: desired.Name, Region: desired.Region})
+	case WebhookSpec:
+		if change.Action == PlanCreate {
+			_, err = client.Webhooks.createFromSpec(desired)
+		} else {
+			var live *Webhook
+			if live, err = client.Webhooks.Get(change.ID); err == nil {
+				_, err = client.Webhooks.reconcile(*live, desired)
+			}
+		}
+	case TemplateSpec:
+		if change.Action == PlanCreate {
+			_, err = client.Templates.Create(CreateTemplateJSONBody{Name: desired.Name, Subject: desired.Subject, Html: desired.Html, Content: desired.Content})
+		} else {
+			_, err = client.Templates.Update(change.ID, UpdateTemplateJSONBody{Subject: &desired.Subject, Html: desired.Html, Content: desired.Content})
+		}
+	case ContactBookSpec:
+		var emoji *string
+		if desired.Emoji != "" {
+			emoji = &desired.Emoji
+		}
+		var properties *map[string]string
+		if desired.Properties != nil {
+			properties = &desired.Properties
+		}
+		if change.Action == PlanCreate {
+			_, err = client.ContactBooks.Create(CreateContactBookJSONBody{Name: desired.Name, Emoji: emoji, Properties: properties})
+		} else {
+			_, err = client.ContactBooks.Update(change.ID, UpdateContactBookJSONBody{Emoji: emoji, Properties: properties})
+		}
+	case ApiKeySpec:
+		payload := CreateApiKeyJSONBody{Name: desired.Name}
+		if desired.Permission != "" {
+			permission := CreateApiKeyJSONBodyPermission(desired.Permission)
+			payload.Permission = &permission
+		}
+		created, err := client.ApiKeys.Create(payload)
+		if err != nil {
+			return "", err
+		}
+		// the old key is only deleted once its replacement exists
+		if change.Action == PlanReplace {
+			return created.Token, deleteApiKey(client, change.ID)
+		}
+		return created.Token, nil
+	case nil:
+		return "", deletePlanResource(client, change)
+	default:
+		err = &APIError{Code: "INTERNAL_ERROR", Message: fmt.Sprintf("unsupported plan resource %T", desired)}
+	}
+	if err != nil {
+		return "", err
+	}
+	return "", nil
+}
+
+func deletePlanResource(client *Client, change PlanChange) error {
+	if change.Resource == "api key" {
+		return deleteApiKey(client, change.ID)
+	}
+	var err *APIError
+	switch change.Resource {
+	case "domain":
+		_, err = client.Domains.Delete(change.ID)
+	case "webhook":
+		_, err = client.Webhooks.Delete(change.ID)
+	case "template":
+		_, err = client.Templates.Delete(change.ID)
+	case "contact book":
+		_, err = client.ContactBooks.Delete(change.ID)
+	}
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// deleteApiKey deletes a key unless the applying client authenticates with
+// it, which would lock the rest of the run out. That case fails with
+// ErrCurrentKey.
+func deleteApiKey(client *Client, id string) error {
+	if _, err := client.ApiKeys.SafeDelete(id); err != nil {
+		return fmt.Errorf("deleting API key %s: %w", id, err)
+	}
+	return nil
+}
+
+func sortedJoin(values []string) string {
+	sorted := append([]string(nil), values...)
+	sort.Strings(sorted)
+	return strings.Join(sorted, ",")
+}
+
+func formatProperties(properties map[string]string) string {
+	pairs := make([]string, 0, len(properties))
+	for name, kind := range properties {
+		pairs = append(pairs, name+":"+kind)
+	}
+	sort.Strings(pairs)
+	return "{" + strings.Join(pairs, ", ") + "}"
+}
+
+func formatBoolPtr(b *bool) string {
+	if b == nil {
+		return "(unset)"
+	}
+	return fmt.Sprint(*b)
+}
+
+func formatStringPtr(s *string) string {
+	if s == nil {
+		return "(unset)"
+	}
+	return fmt.Sprintf("%q", *s)
+}
@@ -0,0 +1,249 @@
+package unsent
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const testAccountSpec = `
+domains:
+  - name: example.com
+    region: us-east-1
+  - name: new.example.com
+    region: eu-west-1
+webhooks:
+  - url: https://hooks.example.com/unsent
+    eventTypes: [email.delivered, email.bounced]
+    active: true
+templates:
+  - name: welcome
+    subject: Welcome aboard
+contactBooks:
+  - name: Customers
+    properties:
+      plan: string
+apiKeys:
+  - name: ci
+    permission: SENDING
+prune: true
+`
+
+func newAccountServer(t *testing.T) (*httptest.Server, *[]string) {
+	var mu sync.Mutex
+	var writes []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" {
+			mu.Lock()
+			writes = append(writes, r.Method+" "+r.URL.Path)
+			mu.Unlock()
+			if r.URL.Path == "/v1/api-keys" {
+				w.Write([]byte(`{"id": "key2", "token": "us_secret"}`))
+				return
+			}
+			w.Write([]byte(`{"id": "new", "success": true}`))
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/domains":
+			w.Write([]byte(`[{"id": "dom1", "name": "example.com"}, {"id": "dom2", "name": "old.example.com"}]`))
+		case "/v1/webhooks":
+			w.Write([]byte(`[{"id": "wh1", "url": "https://hooks.example.com/unsent", "eventTypes": ["email.delivered"], "active": true}]`))
+		case "/v1/webhooks/wh1":
+			w.Write([]byte(`{"id": "wh1", "url": "https://hooks.example.com/unsent", "eventTypes": ["email.delivered"], "description": "prod"}`))
+		case "/v1/templates":
+			w.Write([]byte(`[{"id": "tpl1", "name": "welcome", "subject": "Welcome aboard"}]`))
+		case "/v1/contactBooks":
+			w.Write([]byte(`[{"id": "cb1", "name": "Customers", "properties": {"plan": "string"}}]`))
+		case "/v1/api-keys":
+			w.Write([]byte(`[{"id": "key1", "name": "ci", "permission": "FULL"}]`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	return server, &writes
+}
+
+func TestLoadAccountSpec(t *testing.T) {
+	spec, err := LoadAccountSpec(strings.NewReader(testAccountSpec))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(spec.Domains) != 2 || len(spec.Webhooks[0].EventTypes) != 2 || !spec.Prune || spec.ContactBooks[0].Properties["plan"] != "string" {
+		t.Errorf("unexpected spec: %+v", spec)
+	}
+
+	fromJSON, err := LoadAccountSpec(strings.NewReader(`{"templates": [{"name": "welcome", "subject": "Hi"}]}`))
+	if err != nil || fromJSON.Templates[0].Subject != "Hi" {
+		t.Errorf("expected JSON spec to load, got %+v, %v", fromJSON, err)
+	}
+
+	for _, typo := range []string{"prnue: true\n", "templates:\n  - name: welcome\n    subjet: Hi\n", `{"domains": [{"name": "example.com", "regoin": "us-east-1"}]}`} {
+		if _, err := LoadAccountSpec(strings.NewReader(typo)); err == nil || !strings.Contains(err.Error(), "not found in type") {
+			t.Errorf("expected unknown key in %q to be rejected, got %v", typo, err)
+		}
+	}
+	if empty, err := LoadAccountSpec(strings.NewReader("")); err != nil || empty.Prune {
+		t.Errorf("expected an empty spec to load, got %+v, %v", empty, err)
+	}
+
+	_, err = LoadAccountSpec(strings.NewReader(`
+webhooks:
+  - url: https://hooks.example.com
+    eventTypes: [email.teleported]
+domains:
+  - name: example.com
+  - name: example.com
+    region: us-east-1
+`))
+	if err == nil {
+		t.Fatal("expected validation error")
+	}
+	for _, want := range []string{`unknown event type "email.teleported"`, `domain "example.com" is missing its region`, `domain "example.com" is declared more than once`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error to contain %q, got %v", want, err)
+		}
+	}
+}
+
+func TestPlanAndApplyAccount(t *testing.T) {
+	server, writes := newAccountServer(t)
+	defer server.Close()
+	client, _ := NewClient("key", WithBaseURL(server.URL))
+
+	spec, _ := LoadAccountSpec(strings.NewReader(testAccountSpec))
+	plan, err := PlanAccount(client, spec)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var got []string
+	for _, change := range plan.Changes {
+		got = append(got, string(change.Action)+" "+change.Resource+" "+change.Key)
+	}
+	want := []string{
+		"create domain new.example.com",
+		"delete domain old.example.com",
+		"update webhook https://hooks.example.com/unsent",
+		"replace api key ci",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("expected changes %v, got %v", want, got)
+	}
+	rendered := plan.String()
+	if !strings.HasPrefix(rendered, "Plan: 1 to create, 1 to update, 1 to replace, 1 to delete.") ||
+		!strings.Contains(rendered, "eventTypes: email.delivered -> email.bounced,email.delivered") {
+		t.Errorf("unexpected plan output:\n%s", rendered)
+	}
+
+	var out bytes.Buffer
+	if _, err := ApplyAccountPlan(client, plan, AccountApplyOptions{DryRun: true, Out: &out}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(*writes) != 0 || out.String() != rendered {
+		t.Errorf("expected dry run to only print the plan, got writes %v", *writes)
+	}
+
+	result, err := ApplyAccountPlan(client, plan, AccountApplyOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	wantWrites := []string{
+		"POST /v1/domains",
+		"DELETE /v1/domains/dom2",
+		"PATCH /v1/webhooks/wh1",
+		"POST /v1/api-keys",
+		"DELETE /v1/api-keys/key1",
+	}
+	if strings.Join(*writes, "\n") != strings.Join(wantWrites, "\n") {
+		t.Errorf("expected writes %v, got %v", wantWrites, *writes)
+	}
+	if len(result.Applied) != 4 || result.ApiKeyTokens["ci"] != "us_secret" {
+		t.Errorf("unexpected result: %+v", result)
+	}
+}
+
+func TestApplyAccountPlanCollectsFailures(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/templates" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]interface{}{"error": map[string]string{"code": "BAD_REQUEST", "message": "invalid template"}})
+			return
+		}
+		w.Write([]byte(`{"id": "new"}`))
+	}))
+	defer server.Close()
+	client, _ := NewClient("key", WithBaseURL(server.URL))
+
+	plan := &AccountPlan{}
+	plan.add(PlanChange{Action: PlanCreate, Resource: "template", Key: "welcome", desired: TemplateSpec{Name: "welcome", Subject: "Hi"}})
+	plan.add(PlanChange{Action: PlanCreate, Resource: "contact book", Key: "Customers", desired: ContactBookSpec{Name: "Customers"}})
+
+	result, err := ApplyAccountPlan(client, plan, AccountApplyOptions{})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if len(result.Failures) != 1 || result.Failures[0].Change.Key != "welcome" || len(result.Applied) != 1 {
+		t.Errorf("unexpected result: %+v", result)
+	}
+}
+
+func TestApplyAccountPlanProtectsApiKeys(t *testing.T) {
+	var mu sync.Mutex
+	var writes []string
+	createFails := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" {
+			w.Write([]byte(`[{"id": "key1", "name": "ci", "permission": "FULL", "partialToken": "un_ci...abcd"},
+				{"id": "key2", "name": "deploy", "permission": "FULL", "partialToken": "un_own...cret"}]`))
+			return
+		}
+		mu.Lock()
+		writes = append(writes, r.Method+" "+r.URL.Path)
+		mu.Unlock()
+		if r.Method == "POST" && createFails {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error": {"code": "BAD_REQUEST", "message": "key limit reached"}}`))
+			return
+		}
+		w.Write([]byte(`{"id": "key3", "token": "us_new"}`))
+	}))
+	defer server.Close()
+	client, _ := NewClient("un_own_secret", WithBaseURL(server.URL))
+
+	plan := &AccountPlan{}
+	plan.add(PlanChange{Action: PlanReplace, Resource: "api key", Key: "ci", ID: "key1", desired: ApiKeySpec{Name: "ci", Permission: "SENDING"}})
+	plan.add(PlanChange{Action: PlanDelete, Resource: "api key", Key: "deploy", ID: "key2"})
+	plan.add(PlanChange{Action: PlanReplace, Resource: "api key", Key: "deploy", ID: "key2", desired: ApiKeySpec{Name: "deploy", Permission: "SENDING"}})
+
+	result, err := ApplyAccountPlan(client, plan, AccountApplyOptions{})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	wantWrites := []string{"POST /v1/api-keys", "DELETE /v1/api-keys/key1", "POST /v1/api-keys"}
+	if strings.Join(writes, "\n") != strings.Join(wantWrites, "\n") {
+		t.Errorf("expected writes %v, got %v", wantWrites, writes)
+	}
+	if len(result.Failures) != 2 || !errors.Is(result.Failures[0].Err, ErrCurrentKey) || !errors.Is(result.Failures[1].Err, ErrCurrentKey) {
+		t.Errorf("expected the client's own key to be protected, got %+v", result.Failures)
+	}
+	if result.ApiKeyTokens["deploy"] != "us_new" {
+		t.Errorf("expected the replacement token to be kept, got %v", result.ApiKeyTokens)
+	}
+
+	// a failed create leaves the old key alone
+	writes, createFails = nil, true
+	plan = &AccountPlan{}
+	plan.add(PlanChange{Action: PlanReplace, Resource: "api key", Key: "ci", ID: "key1", desired: ApiKeySpec{Name: "ci", Permission: "SENDING"}})
+	if _, err := ApplyAccountPlan(client, plan, AccountApplyOptions{}); err == nil {
+		t.Fatal("expected error")
+	}
+	if len(writes) != 1 || writes[0] != "POST /v1/api-keys" {
+		t.Errorf("expected no delete after a failed create, got %v", writes)
+	}
+}
@@ -0,0 +1,283 @@
+package unsent
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AnalyticsDay is one day of a domain's (or the account's) time series with
+// derived rates: delivery and bounce against sent, open, click and
+// complaint against delivered. Its JSON date is YYYY-MM-DD, as in CSV.
+type AnalyticsDay struct {
+	Date time.Time `json:"date"`
+	// Domain is empty for the whole account
+	Domain        string  `json:"domain"`
+	Total         int     `json:"total"`
+	Sent          int     `json:"sent"`
+	Delivered     int     `json:"delivered"`
+	Opened        int     `json:"opened"`
+	Clicked       int     `json:"clicked"`
+	Bounced       int     `json:"bounced"`
+	Complained    int     `json:"complained"`
+	Failed        int     `json:"failed"`
+	DeliveryRate  float64 `json:"deliveryRate"`
+	OpenRate      float64 `json:"openRate"`
+	ClickRate     float64 `json:"clickRate"`
+	BounceRate    float64 `json:"bounceRate"`
+	ComplaintRate float64 `json:"complaintRate"`
+}
+
+// MarshalJSON writes the date as YYYY-MM-DD
+func (d AnalyticsDay) MarshalJSON() ([]byte, error) {
+	type plain AnalyticsDay
+	return json.Marshal(struct {
+		Date string `json:"date"`
+		plain
+	}{d.Date.Format(time.DateOnly), plain(d)})
+}
+
+// UnmarshalJSON reads a YYYY-MM-DD or RFC 3339 date
+func (d *AnalyticsDay) UnmarshalJSON(data []byte) error {
+	type plain AnalyticsDay
+	var day struct {
+		Date string `json:"date"`
+		plain
+	}
+	if err := json.Unmarshal(data, &day); err != nil {
+		return err
+	}
+	*d = AnalyticsDay(day.plain)
+	if day.Date == "" {
+		return nil
+	}
+	date, err := parseSeriesDate(day.Date)
+	if err != nil {
+		return err
+	}
+	d.Date = date
+	return nil
+}
+
+func newAnalyticsDay(date time.Time, domain string, row AnalyticsTimeSeries) AnalyticsDay {
+	return AnalyticsDay{
+		Date:          date,
+		Domain:        domain,
+		Total:         row.Total,
+		Sent:          row.Sent,
+		Delivered:     row.Delivered,
+		Opened:        row.Opened,
+		Clicked:       row.Clicked,
+		Bounced:       row.Bounced,
+		Complained:    row.Complained,
+		Failed:        row.Failed,
+		DeliveryRate:  ratio(row.Delivered, row.Sent),
+		OpenRate:      ratio(row.Opened, row.Delivered),
+		ClickRate:     ratio(row.Clicked, row.Delivered),
+		BounceRate:    ratio(row.Bounced, row.Sent),
+		ComplaintRate: ratio(row.Complained, row.Delivered),
+	}
+}
+
+// AnalyticsRange is an inclusive range of UTC dates
+type AnalyticsRange struct {
+	From time.Time
+	To   time.Time
+}
+
+// AnalyticsExportOptions configures AnalyticsClient.ExportTimeSeries
+type AnalyticsExportOptions struct {
+	// Domains to export; an empty name exports the whole account. Defaults to the account only.
+	Domains []string
+	// From and To are inclusive UTC dates, defaulting to the last 30 days.
+	// They are ignored when Ranges is set.
+	From time.Time
+	To   time.Time
+	// Ranges exports several ranges at once; a day covered by more than one
+	// range is exported once
+	Ranges []AnalyticsRange
+	// Concurrency limits parallel requests, defaults to 4
+	Concurrency int
+}
+
+// ExportTimeSeries reads the daily series of every domain, parses the
+// dates, fills days without data with zeros and computes rates. Rows are
+// ordered by domain, then date. Each domain is read once, covering every
+// range. Days are zero-filled only from the earliest day the API returned
+// through today; earlier days are not exported, since the API may not keep
+// that much history and a zero row would look like a day without sends.
+// Days after today are not exported either, and a range starting after
+// today or a row with an unparseable date is an error.
+func (c *AnalyticsClient) ExportTimeSeries(opts AnalyticsExportOptions) ([]AnalyticsDay, error) {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	ranges := opts.Ranges
+	if len(ranges) == 0 {
+		ranges = []AnalyticsRange{{From: opts.From, To: opts.To}}
+	}
+	// the days to export, in order, across all ranges
+	var dates []time.Time
+	seen := make(map[time.Time]bool)
+	for _, r := range ranges {
+		to := today
+		if !r.To.IsZero() {
+			to = r.To.UTC().Truncate(24 * time.Hour)
+		}
+		from := to.AddDate(0, 0, -29)
+		if !r.From.IsZero() {
+			from = r.From.UTC().Truncate(24 * time.Hour)
+		}
+		if from.After(to) {
+			return nil, fmt.Errorf("export range %s to %s is empty", from.Format(time.DateOnly), to.Format(time.DateOnly))
+		}
+		if from.After(today) {
+			return nil, fmt.Errorf("export range %s to %s starts after today", from.Format(time.DateOnly), to.Format(time.DateOnly))
+		}
+		if to.After(today) {
+			to = today
+		}
+		for date := from; !date.After(to); date = date.AddDate(0, 0, 1) {
+			if !seen[date] {
+				seen[date] = true
+				dates = append(dates, date)
+			}
+		}
+	}
+	sort.Slice(dates, func(i, j int) bool { return dates[i].Before(dates[j]) })
+	domains := opts.Domains
+	if len(domains) == 0 {
+		domains = []string{""}
+	}
+
+	// the API counts days back from today
+	days := strconv.Itoa(int(math.Ceil(today.Sub(dates[0]).Hours()/24)) + 1)
+	results := make([][]AnalyticsDay, len(domains))
+	errs := make([]error, len(domains))
+	forEachConcurrent(len(domains), opts.Concurrency, func(i int) {
+		params := GetTimeSeriesParams{Days: &days}
+		if domains[i] != "" {
+			params.Domain = &domains[i]
+		}
+		series, apiErr := c.GetTimeSeries(params)
+		if apiErr != nil {
+			errs[i] = apiErr
+			return
+		}
+		byDate := make(map[time.Time]AnalyticsTimeSeries)
+		var covered time.Time
+		for _, row := range series.Data {
+			date, err := parseSeriesDate(row.Date)
+			if err != nil {
+				errs[i] = fmt.Errorf("time series of %q: invalid date %q", domains[i], row.Date)
+				return
+			}
+			date = date.UTC().Truncate(24 * time.Hour)
+			byDate[date] = row
+			if covered.IsZero() || date.Before(covered) {
+				covered = date
+			}
+		}
+		for _, date := range dates {
+			if covered.IsZero() || date.Before(covered) {
+				continue
+			}
+			results[i] = append(results[i], newAnalyticsDay(date, domains[i], byDate[date]))
+		}
+	})
+
+	var rows []AnalyticsDay
+	for i := range domains {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+		rows = append(rows, results[i]...)
+	}
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i].Domain < rows[j].Domain })
+	return rows, nil
+}
+
+// analyticsColumns are the CSV header and columnar JSON keys, in order
+var analyticsColumns = []string{
+	"date", "domain", "total", "sent", "delivered", "opened", "clicked", "bounced", "complained", "failed",
+	"deliveryRate", "openRate", "clickRate", "bounceRate", "complaintRate",
+}
+
+func (d AnalyticsDay) values() []interface{} {
+	return []interface{}{
+		d.Date.Format(time.DateOnly), d.Domain, d.Total, d.Sent, d.Delivered, d.Opened, d.Clicked, d.Bounced, d.Complained, d.Failed,
+		d.DeliveryRate, d.OpenRate, d.ClickRate, d.BounceRate, d.ComplaintRate,
+	}
+}
+
+// WriteAnalyticsCSV writes rows as CSV with a header line and YYYY-MM-DD dates
+func WriteAnalyticsCSV(w io.Writer, rows []AnalyticsDay) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(analyticsColumns); err != nil {
+		return err
+	}
+	record := make([]string, len(analyticsColumns))
+	for _, row := range rows {
+		for i, value := range row.values() {
+			switch v := value.(type) {
+			case float64:
+				record[i] = strconv.FormatFloat(v, 'f', -1, 64)
+			default:
+				record[i] = fmt.Sprint(v)
+			}
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// WriteAnalyticsNDJSON writes one JSON object per row and line
+func WriteAnalyticsNDJSON(w io.Writer, rows []AnalyticsDay) error {
+	encoder := json.NewEncoder(w)
+	for _, row := range rows {
+		if err := encoder.Encode(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteAnalyticsColumnarJSON writes a single JSON object mapping each column
+// to an array of values, e.g. {"date": ["2025-01-01", ...], "sent": [120, ...]},
+// which loads directly into dataframe and columnar BI tools
+func WriteAnalyticsColumnarJSON(w io.Writer, rows []AnalyticsDay) error {
+	columns := make([][]interface{}, len(analyticsColumns))
+	for i := range columns {
+		columns[i] = make([]interface{}, 0, len(rows))
+	}
+	for _, row := range rows {
+		for i, value := range row.values() {
+			columns[i] = append(columns[i], value)
+		}
+	}
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, name := range analyticsColumns {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		key, _ := json.Marshal(name)
+		values, err := json.Marshal(columns[i])
+		if err != nil {
+			return err
+		}
+		b.Write(key)
+		b.WriteByte(':')
+		b.Write(values)
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
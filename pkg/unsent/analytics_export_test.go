@@ -0,0 +1,136 @@
+package unsent
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAnalytics_ExportTimeSeries(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("domain") {
+		case "a.com":
+			w.Write([]byte(`{"data": [
+				{"date": "2025-01-03", "sent": 200, "delivered": 190, "opened": 38, "bounced": 4},
+				{"date": "2025-01-01", "sent": 100, "delivered": 100, "opened": 50, "clicked": 10}
+			]}`))
+		case "b.com":
+			w.Write([]byte(`{"data": [{"date": "2025-01-02T00:00:00Z", "sent": 10, "delivered": 8, "complained": 1}]}`))
+		default:
+			t.Errorf("unexpected query %s", r.URL.RawQuery)
+		}
+	}))
+	defer server.Close()
+	client, _ := NewClient("key", WithBaseURL(server.URL))
+
+	rows, err := client.Analytics.ExportTimeSeries(AnalyticsExportOptions{
+		Domains: []string{"b.com", "a.com"},
+		From:    time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		To:      time.Date(2025, 1, 3, 0, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// b.com's series starts on 2025-01-02, so 2025-01-01 is not zero-filled
+	if len(rows) != 5 {
+		t.Fatalf("expected 3 days for a.com and 2 for b.com, got %d", len(rows))
+	}
+	if rows[0].Domain != "a.com" || !rows[0].Date.Equal(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)) || rows[0].OpenRate != 0.5 {
+		t.Errorf("unexpected first row: %+v", rows[0])
+	}
+	if rows[1].Sent != 0 || rows[1].DeliveryRate != 0 {
+		t.Errorf("expected a zero-filled gap on 2025-01-02, got %+v", rows[1])
+	}
+	if rows[2].BounceRate != 0.02 || rows[3].Domain != "b.com" || rows[3].ComplaintRate != 0.125 {
+		t.Errorf("unexpected rates: %+v, %+v", rows[2], rows[3])
+	}
+
+	var csvOut bytes.Buffer
+	if err := WriteAnalyticsCSV(&csvOut, rows[:1]); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	wantCSV := "date,domain,total,sent,delivered,opened,clicked,bounced,complained,failed,deliveryRate,openRate,clickRate,bounceRate,complaintRate\n" +
+		"2025-01-01,a.com,0,100,100,50,10,0,0,0,1,0.5,0.1,0,0\n"
+	if csvOut.String() != wantCSV {
+		t.Errorf("unexpected CSV:\n%s", csvOut.String())
+	}
+
+	var ndjson bytes.Buffer
+	WriteAnalyticsNDJSON(&ndjson, rows)
+	if lines := strings.Split(strings.TrimSpace(ndjson.String()), "\n"); len(lines) != 5 || !strings.Contains(lines[4], `"date":"2025-01-03"`) || !strings.Contains(lines[4], `"domain":"b.com"`) {
+		t.Errorf("unexpected NDJSON:\n%s", ndjson.String())
+	}
+	var decoded AnalyticsDay
+	if err := json.Unmarshal([]byte(strings.SplitN(ndjson.String(), "\n", 2)[0]), &decoded); err != nil || !decoded.Date.Equal(rows[0].Date) || decoded.Sent != 100 {
+		t.Errorf("expected NDJSON rows to decode, got %+v, %v", decoded, err)
+	}
+
+	var columnar bytes.Buffer
+	if err := WriteAnalyticsColumnarJSON(&columnar, rows); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var columns map[string][]interface{}
+	if err := json.Unmarshal(columnar.Bytes(), &columns); err != nil {
+		t.Fatalf("invalid columnar JSON: %v", err)
+	}
+	if len(columns) != 15 || len(columns["sent"]) != 5 || columns["date"][2] != "2025-01-03" || columns["sent"][2] != float64(200) {
+		t.Errorf("unexpected columns: %v", columns)
+	}
+}
+
+func TestAnalytics_ExportTimeSeriesRanges(t *testing.T) {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if days := r.URL.Query().Get("days"); days != "11" {
+			t.Errorf("expected 11 days back to the earliest range, got %s", days)
+		}
+		w.Write([]byte(`{"data": [{"date": "` + today.AddDate(0, 0, -10).Format(time.DateOnly) + `"}, {"date": "` + today.AddDate(0, 0, -9).Format(time.DateOnly) + `", "sent": 5}]}`))
+	}))
+	defer server.Close()
+	client, _ := NewClient("key", WithBaseURL(server.URL))
+
+	rows, err := client.Analytics.ExportTimeSeries(AnalyticsExportOptions{
+		Domains: []string{"a.com"},
+		Ranges: []AnalyticsRange{
+			{From: today.AddDate(0, 0, -10), To: today.AddDate(0, 0, -8)},
+			{From: today.AddDate(0, 0, -9), To: today.AddDate(0, 0, -9)},
+			{From: today.AddDate(0, 0, -1), To: today.AddDate(0, 0, 5)},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if requests != 1 {
+		t.Errorf("expected one request for all ranges, got %d", requests)
+	}
+	// 3 days of the first range, the overlap once, yesterday and today
+	if len(rows) != 5 || !rows[1].Date.Equal(today.AddDate(0, 0, -9)) || rows[1].Sent != 5 || !rows[4].Date.Equal(today) {
+		t.Errorf("unexpected rows: %+v", rows)
+	}
+
+	if _, err := client.Analytics.ExportTimeSeries(AnalyticsExportOptions{From: today.AddDate(0, 0, 1), To: today.AddDate(0, 0, 2)}); err == nil || !strings.Contains(err.Error(), "after today") {
+		t.Errorf("expected a range after today to be rejected, got %v", err)
+	}
+}
+
+func TestAnalytics_ExportTimeSeriesInvalidDate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data": [{"date": "2025-01-01", "sent": 1}, {"date": "Jan 2", "sent": 7}]}`))
+	}))
+	defer server.Close()
+	client, _ := NewClient("key", WithBaseURL(server.URL))
+
+	_, err := client.Analytics.ExportTimeSeries(AnalyticsExportOptions{
+		From: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		To:   time.Date(2025, 1, 3, 0, 0, 0, 0, time.UTC),
+	})
+	if err == nil || !strings.Contains(err.Error(), `"Jan 2"`) {
+		t.Errorf("expected an invalid date error, got %v", err)
+	}
+}
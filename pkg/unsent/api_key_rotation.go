@@ -0,0 +1,260 @@
+package unsent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ErrCurrentKey is returned when an operation would delete the API key the
+// client itself is using
+var ErrCurrentKey = errors.New("refusing to delete the API key this client is using")
+
+// SecretSink stores a newly created API key, e.g. in a secret manager or a
+// deployment's environment
+type SecretSink interface {
+	StoreAPIKey(ctx context.Context, key ApiKey, token string) error
+}
+
+// SecretSinkFunc adapts a function to SecretSink
+type SecretSinkFunc func(ctx context.Context, key ApiKey, token string) error
+
+// StoreAPIKey implements SecretSink
+func (f SecretSinkFunc) StoreAPIKey(ctx context.Context, key ApiKey, token string) error {
+	return f(ctx, key, token)
+}
+
+// KeyRotationOptions configures ApiKeysClient.Rotate
+type KeyRotationOptions struct {
+	// Sink receives the new token once it is verified and before the old key
+	// is deleted, required
+	Sink SecretSink
+	// Verify is an extra check run with a client using the new key
+	Verify func(ctx context.Context, client *Client) error
+	// SwitchClient makes this client use the new key before the old one is
+	// deleted. Without it, rotating the client's own key fails with ErrCurrentKey.
+	// Client.Key is written without synchronization, so the client must not
+	// be used by other goroutines while Rotate runs.
+	SwitchClient bool
+}
+
+// KeyRotation is the outcome of a rotation
+type KeyRotation struct {
+	Old ApiKey
+	New ApiKey
+	// OldDeleted is false when deleting the old key failed after the new
+	// key was stored and verified
+	OldDeleted bool
+}
+
+// minVisibleTokenChars is how many characters a masked partial token must
+// show to identify a single key
+const minVisibleTokenChars = 8
+
+// matchesToken reports whether the key's partial token is a masked form of
+// token, e.g. "un_ab...wxyz". A mask must keep characters on both ends and
+// show at least minVisibleTokenChars in total; shorter masks such as
+// "un_****" could belong to any key and never match. Keys without a partial
+// token never match.
+func (k ApiKey) matchesToken(token string) bool {
+	isMask := func(r rune) bool { return r == '.' || r == '*' || r == '…' }
+	parts := strings.FieldsFunc(k.PartialToken, isMask)
+	if len(parts) == 0 || token == "" {
+		return false
+	}
+	if !strings.ContainsFunc(k.PartialToken, isMask) {
+		return token == k.PartialToken
+	}
+	first, _ := utf8.DecodeRuneInString(k.PartialToken)
+	last, _ := utf8.DecodeLastRuneInString(k.PartialToken)
+	if isMask(first) || isMask(last) || len(strings.Join(parts, "")) < minVisibleTokenChars {
+		return false
+	}
+	if !strings.HasPrefix(token, parts[0]) || !strings.HasSuffix(token, parts[len(parts)-1]) {
+		return false
+	}
+	// the visible fragments must appear in order without overlapping
+	rest := token
+	for _, part := range parts {
+		i := strings.Index(rest, part)
+		if i < 0 {
+			return false
+		}
+		rest = rest[i+len(part):]
+	}
+	return true
+}
+
+// CreatedTime parses CreatedAt
+func (k ApiKey) CreatedTime() (time.Time, error) {
+	return time.Parse(time.RFC3339Nano, k.CreatedAt)
+}
+
+// Current finds the API key this client is using by its partial token. It
+// fails when no key or more than one key matches.
+func (c *ApiKeysClient) Current() (*ApiKey, error) {
+	keys, apiErr := c.List()
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	var current *ApiKey
+	for _, key := range *keys {
+		if !key.matchesToken(c.client.Key) {
+			continue
+		}
+		if current != nil {
+			return nil, fmt.Errorf("API key of this client is ambiguous: %s and %s both match", current.ID, key.ID)
+		}
+		current = &key
+	}
+	if current == nil {
+		return nil, fmt.Errorf("API key of this client not found")
+	}
+	return current, nil
+}
+
+// SafeDelete deletes an API key unless it is the one the client is using,
+// in which case it returns ErrCurrentKey
+func (c *ApiKeysClient) SafeDelete(id string) (*ApiKeyDeleteResponse, error) {
+	key, err := c.find(id)
+	if err != nil {
+		return nil, err
+	}
+	if key.matchesToken(c.client.Key) {
+		return nil, ErrCurrentKey
+	}
+	resp, apiErr := c.Delete(id)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	return resp, nil
+}
+
+func (c *ApiKeysClient) find(id string) (*ApiKey, error) {
+	keys, apiErr := c.List()
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	for _, key := range *keys {
+		if key.ID == id {
+			return &key, nil
+		}
+	}
+	return nil, fmt.Errorf("API key %s not found", id)
+}
+
+// StaleKeys lists the keys created more than maxAge ago, oldest first
+func (c *ApiKeysClient) StaleKeys(maxAge time.Duration) ([]ApiKey, error) {
+	keys, apiErr := c.List()
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	cutoff := time.Now().Add(-maxAge)
+	var stale []ApiKey
+	created := make(map[string]time.Time)
+	for _, key := range *keys {
+		at, err := key.CreatedTime()
+		if err != nil {
+			return nil, fmt.Errorf("API key %s: invalid createdAt %q", key.ID, key.CreatedAt)
+		}
+		if at.Before(cutoff) {
+			stale = append(stale, key)
+			created[key.ID] = at
+		}
+	}
+	sort.SliceStable(stale, func(i, j int) bool { return created[stale[i].ID].Before(created[stale[j].ID]) })
+	return stale, nil
+}
+
+// Rotate replaces an API key: it creates a key with the same name and
+// permission, verifies the new key works, hands the token to opts.Sink and
+// then deletes the old key. If verifying or storing fails the new key is
+// deleted again and the old key is left untouched, so the sink never holds
+// a token that does not work.
+func (c *ApiKeysClient) Rotate(ctx context.Context, id string, opts KeyRotationOptions) (*KeyRotation, error) {
+	if opts.Sink == nil {
+		return nil, fmt.Errorf("rotate API key %s: a secret sink is required", id)
+	}
+	old, err := c.find(id)
+	if err != nil {
+		return nil, err
+	}
+	current := old.matchesToken(c.client.Key)
+	if current && !opts.SwitchClient {
+		return nil, ErrCurrentKey
+	}
+
+	payload := CreateApiKeyJSONBody{Name: old.Name}
+	if old.Permission != "" {
+		permission := CreateApiKeyJSONBodyPermission(old.Permission)
+		payload.Permission = &permission
+	}
+	created, apiErr := c.Create(payload)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if created.Token == "" {
+		return nil, fmt.Errorf("API key %s was created without a token", created.ID)
+	}
+	rotation := &KeyRotation{Old: *old, New: ApiKey{ID: created.ID, Name: old.Name, Permission: old.Permission}}
+
+	rollback := func(cause error) (*KeyRotation, error) {
+		if _, apiErr := c.Delete(created.ID); apiErr != nil {
+			return nil, fmt.Errorf("%w (deleting new API key %s also failed: %v)", cause, created.ID, apiErr)
+		}
+		return nil, cause
+	}
+	if err := c.verifyKey(ctx, rotation.New, created.Token, opts.Verify); err != nil {
+		return rollback(fmt.Errorf("verify new API key: %w", err))
+	}
+	if err := opts.Sink.StoreAPIKey(ctx, rotation.New, created.Token); err != nil {
+		return rollback(fmt.Errorf("store new API key: %w", err))
+	}
+
+	if current {
+		c.client.Key = created.Token
+	}
+	if _, apiErr := c.Delete(old.ID); apiErr != nil {
+		return rotation, fmt.Errorf("delete old API key %s: %w", old.ID, apiErr)
+	}
+	rotation.OldDeleted = true
+	return rotation, nil
+}
+
+// verifyKey checks the new key against the health endpoint, confirms it was
+// listed with the expected permission and, for FULL keys, that it can read
+// the key list itself
+func (c *ApiKeysClient) verifyKey(ctx context.Context, key ApiKey, token string, verify func(context.Context, *Client) error) error {
+	probe := c.client.withKey(token)
+	if _, apiErr := probe.System.Health(); apiErr != nil {
+		return apiErr
+	}
+	listed, err := c.find(key.ID)
+	if err != nil {
+		return err
+	}
+	if key.Permission != "" && listed.Permission != key.Permission {
+		return fmt.Errorf("expected permission %s, got %s", key.Permission, listed.Permission)
+	}
+	if CreateApiKeyJSONBodyPermission(key.Permission) == FULL {
+		if _, apiErr := probe.ApiKeys.List(); apiErr != nil {
+			return apiErr
+		}
+	}
+	if verify != nil {
+		return verify(ctx, probe)
+	}
+	return nil
+}
+
+// withKey returns a copy of the client that authenticates with key
+func (c *Client) withKey(key string) *Client {
+	clone := *c
+	clone.Key = key
+	clone.initResources()
+	return &clone
+}
@@ -0,0 +1,248 @@
+package unsent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// keyServer is an in-memory API key store. Tokens look like "un_<id>_secret"
+// and are listed as "un_<id>...cret".
+type keyServer struct {
+	t        *testing.T
+	mu       sync.Mutex
+	keys     []ApiKey
+	next     int
+	requests []string
+}
+
+func (s *keyServer) add(id, permission string, created time.Time) {
+	s.keys = append(s.keys, ApiKey{ID: id, Name: "key " + id, PartialToken: "un_" + id + "...cret", Permission: permission, CreatedAt: created.Format(time.RFC3339)})
+}
+
+func (s *keyServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	s.requests = append(s.requests, r.Method+" "+r.URL.Path+" "+token)
+	switch {
+	case r.URL.Path == "/v1/health":
+		w.Write([]byte(`{"status": "ok"}`))
+	case r.URL.Path == "/v1/api-keys" && r.Method == "GET":
+		json.NewEncoder(w).Encode(s.keys)
+	case r.URL.Path == "/v1/api-keys" && r.Method == "POST":
+		var body CreateApiKeyJSONBody
+		json.NewDecoder(r.Body).Decode(&body)
+		s.next++
+		id := fmt.Sprintf("new%d", s.next)
+		s.keys = append(s.keys, ApiKey{ID: id, Name: body.Name, PartialToken: "un_" + id + "...cret", Permission: string(*body.Permission), CreatedAt: time.Now().Format(time.RFC3339)})
+		w.Write([]byte(`{"id": "` + id + `", "token": "un_` + id + `_secret"}`))
+	case strings.HasPrefix(r.URL.Path, "/v1/api-keys/") && r.Method == "DELETE":
+		id := strings.TrimPrefix(r.URL.Path, "/v1/api-keys/")
+		for i, key := range s.keys {
+			if key.ID == id {
+				s.keys = append(s.keys[:i], s.keys[i+1:]...)
+				break
+			}
+		}
+		w.Write([]byte(`{"id": "` + id + `", "deleted": true}`))
+	default:
+		s.t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+	}
+}
+
+func (s *keyServer) ids() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var ids []string
+	for _, key := range s.keys {
+		ids = append(ids, key.ID)
+	}
+	return ids
+}
+
+func newKeyServer(t *testing.T) (*keyServer, *httptest.Server) {
+	keys := &keyServer{t: t}
+	keys.add("own", "FULL", time.Now().AddDate(0, 0, -10))
+	keys.add("ci", "SENDING", time.Now().AddDate(0, -6, 0))
+	keys.add("old", "SENDING", time.Now().AddDate(-1, 0, 0))
+	return keys, httptest.NewServer(keys)
+}
+
+func TestApiKey_MatchesToken(t *testing.T) {
+	cases := []struct {
+		partial, token string
+		want           bool
+	}{
+		{"un_ab...wxyz", "un_ab1234wxyz", true},
+		{"un_ab...wxyz", "un_ab1234wxya", false},
+		{"un_ab****", "un_ab1234", false},
+		{"un_****", "un_ab1234", false},
+		{"****wxyz", "un_ab1234wxyz", false},
+		{"un_a...z", "un_ab1234wxyz", false},
+		{"un_ab…wxyz", "un_abwxyz", true},
+		{"un_ab...ab", "un_ab", false},
+		{"un_ab1234", "un_ab1234", true},
+		{"un_ab", "un_ab1234", false},
+		{"", "un_ab1234", false},
+	}
+	for _, c := range cases {
+		if got := (ApiKey{PartialToken: c.partial}).matchesToken(c.token); got != c.want {
+			t.Errorf("expected %q matching %q to be %v", c.partial, c.token, c.want)
+		}
+	}
+}
+
+func TestApiKeys_StaleKeysAndSafeDelete(t *testing.T) {
+	keys, server := newKeyServer(t)
+	defer server.Close()
+	client, _ := NewClient("un_own_secret", WithBaseURL(server.URL))
+
+	stale, err := client.ApiKeys.StaleKeys(90 * 24 * time.Hour)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(stale) != 2 || stale[0].ID != "old" || stale[1].ID != "ci" {
+		t.Errorf("expected [old ci], got %+v", stale)
+	}
+
+	current, err := client.ApiKeys.Current()
+	if err != nil || current.ID != "own" {
+		t.Errorf("expected the own key, got %+v, %v", current, err)
+	}
+	if _, err := client.ApiKeys.SafeDelete("own"); !errors.Is(err, ErrCurrentKey) {
+		t.Errorf("expected ErrCurrentKey, got %v", err)
+	}
+	if resp, err := client.ApiKeys.SafeDelete("old"); err != nil || !resp.Deleted {
+		t.Errorf("expected old to be deleted, got %+v, %v", resp, err)
+	}
+	if ids := keys.ids(); len(ids) != 2 || ids[0] != "own" {
+		t.Errorf("expected [own ci], got %v", ids)
+	}
+}
+
+func TestApiKeys_Rotate(t *testing.T) {
+	keys, server := newKeyServer(t)
+	defer server.Close()
+	client, _ := NewClient("un_own_secret", WithBaseURL(server.URL))
+
+	var stored []string
+	sink := SecretSinkFunc(func(ctx context.Context, key ApiKey, token string) error {
+		stored = append(stored, key.ID+"="+token)
+		return nil
+	})
+	var verifiedWith string
+	rotation, err := client.ApiKeys.Rotate(context.Background(), "ci", KeyRotationOptions{
+		Sink: sink,
+		Verify: func(ctx context.Context, probe *Client) error {
+			verifiedWith = probe.Key
+			if probe == client || probe.HTTPClient != client.HTTPClient || probe.System.client != probe {
+				t.Errorf("expected the probe to be a full copy of the client")
+			}
+			if len(stored) != 0 {
+				t.Errorf("expected verification before the token is stored")
+			}
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rotation.New.ID != "new1" || rotation.New.Name != "key ci" || rotation.New.Permission != "SENDING" || !rotation.OldDeleted {
+		t.Errorf("unexpected rotation: %+v", rotation)
+	}
+	if len(stored) != 1 || stored[0] != "new1=un_new1_secret" || verifiedWith != "un_new1_secret" {
+		t.Errorf("expected the new token to be stored and verified, got %v, %q", stored, verifiedWith)
+	}
+	keys.mu.Lock()
+	var healthChecked bool
+	for _, req := range keys.requests {
+		healthChecked = healthChecked || req == "GET /v1/health un_new1_secret"
+	}
+	keys.mu.Unlock()
+	if !healthChecked {
+		t.Errorf("expected a health check with the new key, got %v", keys.requests)
+	}
+	if ids := keys.ids(); strings.Join(ids, ",") != "own,old,new1" {
+		t.Errorf("expected ci to be replaced, got %v", ids)
+	}
+	if client.Key != "un_own_secret" {
+		t.Errorf("expected the client key to be unchanged, got %s", client.Key)
+	}
+
+	// a failed verification rolls back without storing the token
+	failingVerify := func(ctx context.Context, probe *Client) error { return errors.New("cannot send") }
+	if _, err := client.ApiKeys.Rotate(context.Background(), "old", KeyRotationOptions{Sink: sink, Verify: failingVerify}); err == nil || !strings.Contains(err.Error(), "cannot send") {
+		t.Errorf("expected the verification error, got %v", err)
+	}
+	if len(stored) != 1 {
+		t.Errorf("expected an unverified token not to reach the sink, got %v", stored)
+	}
+	if ids := keys.ids(); strings.Join(ids, ",") != "own,old,new1" {
+		t.Errorf("expected the unverified key to be deleted, got %v", ids)
+	}
+
+	// a failing sink rolls back
+	failing := SecretSinkFunc(func(ctx context.Context, key ApiKey, token string) error {
+		return errors.New("vault unavailable")
+	})
+	if _, err := client.ApiKeys.Rotate(context.Background(), "old", KeyRotationOptions{Sink: failing}); err == nil || !strings.Contains(err.Error(), "vault unavailable") {
+		t.Errorf("expected the sink error, got %v", err)
+	}
+	if ids := keys.ids(); strings.Join(ids, ",") != "own,old,new1" {
+		t.Errorf("expected the new key to be deleted and old kept, got %v", ids)
+	}
+}
+
+func TestApiKeys_RotateCurrent(t *testing.T) {
+	keys, server := newKeyServer(t)
+	defer server.Close()
+	client, _ := NewClient("un_own_secret", WithBaseURL(server.URL))
+	sink := SecretSinkFunc(func(ctx context.Context, key ApiKey, token string) error { return nil })
+
+	if _, err := client.ApiKeys.Rotate(context.Background(), "own", KeyRotationOptions{Sink: sink}); !errors.Is(err, ErrCurrentKey) {
+		t.Errorf("expected ErrCurrentKey, got %v", err)
+	}
+	if ids := keys.ids(); len(ids) != 3 {
+		t.Errorf("expected no key to be created, got %v", ids)
+	}
+
+	rotation, err := client.ApiKeys.Rotate(context.Background(), "own", KeyRotationOptions{Sink: sink, SwitchClient: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if client.Key != "un_new1_secret" || !rotation.OldDeleted {
+		t.Errorf("expected the client to switch to the new key, got %s, %+v", client.Key, rotation)
+	}
+	keys.mu.Lock()
+	last := keys.requests[len(keys.requests)-1]
+	keys.mu.Unlock()
+	if last != "DELETE /v1/api-keys/own un_new1_secret" {
+		t.Errorf("expected the old key to be deleted with the new one, got %s", last)
+	}
+}
+
+func TestApiKeys_PrefixOnlyMasks(t *testing.T) {
+	keys := &keyServer{t: t}
+	server := httptest.NewServer(keys)
+	defer server.Close()
+	keys.keys = []ApiKey{
+		{ID: "own", PartialToken: "un_****", Permission: "FULL"},
+		{ID: "ci", PartialToken: "un_****", Permission: "SENDING"},
+	}
+	client, _ := NewClient("un_own_secret", WithBaseURL(server.URL))
+
+	if _, err := client.ApiKeys.Current(); err == nil {
+		t.Errorf("expected a prefix-only mask not to identify the current key")
+	}
+	if resp, err := client.ApiKeys.SafeDelete("ci"); err != nil || !resp.Deleted {
+		t.Errorf("expected ci to be deleted, got %+v, %v", resp, err)
+	}
+}
@@ -0,0 +1,294 @@
+package unsent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// SubjectTestMetric is the rate used to pick the winning subject
+type SubjectTestMetric string
+
+const (
+	SubjectTestOpenRate  SubjectTestMetric = "openRate"
+	SubjectTestClickRate SubjectTestMetric = "clickRate"
+)
+
+// SubjectTestOptions configures CampaignsClient.RunSubjectTest
+type SubjectTestOptions struct {
+	// Name prefixes the temporary books and campaigns created by the test
+	Name          string
+	ContactBookID string
+	From          string
+	Html          *string
+	Content       *string
+	PreviewText   *string
+	// Subjects are the variants under test, at least two
+	Subjects []string
+	// TestFraction is the share of the book split across the test cohorts, defaults to 0.2
+	TestFraction float64
+	// Seed makes cohort assignment reproducible
+	Seed int64
+	// Window is how long to wait after the test sends before comparing results, defaults to 4 hours
+	Window time.Duration
+	// Metric decides the winner, defaults to open rate
+	Metric SubjectTestMetric
+	// DeleteTestBooks removes the cohort books once their campaigns reach
+	// SENT, FAILED or CANCELLED, polled with PollOptions
+	DeleteTestBooks bool
+	PollOptions     PollOptions
+	// Concurrency limits the number of in-flight contact writes, defaults to 4
+	Concurrency int
+}
+
+// SubjectVariantResult reports how a single subject performed
+type SubjectVariantResult struct {
+	Subject    string
+	BookID     string
+	CampaignID string
+	Recipients int
+	Delivered  int
+	Opened     int
+	Clicked    int
+	OpenRate   float64
+	ClickRate  float64
+}
+
+// SubjectTestFailure records a contact that could not be added to a cohort
+// or remainder book; the test carries on without it
+type SubjectTestFailure struct {
+	Email  string
+	BookID string
+	Err    *APIError
+}
+
+// SubjectTestReport describes the cohorts, their results and the decision
+type SubjectTestReport struct {
+	Seed     int64
+	Metric   SubjectTestMetric
+	Variants []SubjectVariantResult
+	// Winner is the index of the winning variant
+	Winner              int
+	WinningSubject      string
+	Decision            string
+	RemainderBookID     string
+	RemainderCampaignID string
+	RemainderRecipients int
+	Failures            []SubjectTestFailure
+	StartedAt           time.Time
+	DecidedAt           time.Time
+}
+
+// RunSubjectTest splits a contact book into randomized cohorts, sends one
+// campaign per subject line to a cohort each, waits for the test window,
+// compares open or click rates and sends the winning subject to the rest of
+// the book. Each cohort and the remainder are stored in temporary contact
+// books. Unsubscribed contacts are left out, and contacts that cannot be
+// added to a book are listed in Failures. On error the partial report lists
+// everything created so far.
+func (c *CampaignsClient) RunSubjectTest(ctx context.Context, opts SubjectTestOptions) (*SubjectTestReport, error) {
+	if len(opts.Subjects) < 2 {
+		return nil, errors.New("a subject test needs at least two subjects")
+	}
+	if opts.TestFraction <= 0 || opts.TestFraction >= 1 {
+		opts.TestFraction = 0.2
+	}
+	if opts.Window <= 0 {
+		opts.Window = 4 * time.Hour
+	}
+	if opts.Metric == "" {
+		opts.Metric = SubjectTestOpenRate
+	}
+	if opts.Name == "" {
+		opts.Name = "Subject test"
+	}
+
+	book, apiErr := c.client.ContactBooks.Get(opts.ContactBookID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	contacts, apiErr := c.client.Contacts.ListAll(opts.ContactBookID, 0)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	cohorts, remainder, err := splitSubjectTestCohorts(contacts, len(opts.Subjects), opts.TestFraction, opts.Seed)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &SubjectTestReport{Seed: opts.Seed, Metric: opts.Metric, StartedAt: time.Now()}
+	for i, subject := range opts.Subjects {
+		label := fmt.Sprintf("%s - variant %c", opts.Name, 'A'+i)
+		bookID, failures, err := c.createCohortBook(book, label, cohorts[i], opts.Concurrency)
+		report.Failures = append(report.Failures, failures...)
+		// a book that was created but left empty still needs cleaning up
+		if bookID != "" {
+			variant := SubjectVariantResult{Subject: subject, BookID: bookID, Recipients: len(cohorts[i]) - len(failures)}
+			report.Variants = append(report.Variants, variant)
+		}
+		if err != nil {
+			return report, err
+		}
+
+		campaign, apiErr := c.Create(opts.campaign(label, subject, bookID))
+		if apiErr != nil {
+			return report, apiErr
+		}
+		report.Variants[i].CampaignID = campaign.ID
+	}
+
+	if err := sleepContext(ctx, opts.Window); err != nil {
+		return report, err
+	}
+
+	for i := range report.Variants {
+		v := &report.Variants[i]
+		detail, apiErr := c.Get(v.CampaignID)
+		if apiErr != nil {
+			return report, apiErr
+		}
+		v.Delivered, v.Opened, v.Clicked = detail.Delivered, detail.Opened, detail.Clicked
+		v.OpenRate = ratio(detail.Opened, detail.Delivered)
+		v.ClickRate = ratio(detail.Clicked, detail.Delivered)
+	}
+	report.Winner = pickSubjectWinner(report.Variants, opts.Metric)
+	winner := report.Variants[report.Winner]
+	report.WinningSubject = winner.Subject
+	report.DecidedAt = time.Now()
+	report.Decision = fmt.Sprintf("variant %c %q won with %s %.2f%% over %d delivered",
+		'A'+report.Winner, winner.Subject, opts.Metric, 100*winner.metric(opts.Metric), winner.Delivered)
+
+	if len(remainder) > 0 {
+		label := fmt.Sprintf("%s - winner", opts.Name)
+		bookID, failures, err := c.createCohortBook(book, label, remainder, opts.Concurrency)
+		report.RemainderBookID = bookID
+		report.Failures = append(report.Failures, failures...)
+		if err != nil {
+			return report, err
+		}
+		report.RemainderRecipients = len(remainder) - len(failures)
+		campaign, apiErr := c.Create(opts.campaign(label, winner.Subject, bookID))
+		if apiErr != nil {
+			return report, apiErr
+		}
+		report.RemainderCampaignID = campaign.ID
+	}
+
+	if opts.DeleteTestBooks {
+		for _, v := range report.Variants {
+			// a book must outlive the campaign still sending to it
+			if err := c.waitCampaignDone(ctx, v.CampaignID, opts.PollOptions); err != nil {
+				return report, err
+			}
+			if _, apiErr := c.client.ContactBooks.Delete(v.BookID); apiErr != nil {
+				return report, apiErr
+			}
+		}
+	}
+	return report, nil
+}
+
+// waitCampaignDone watches a campaign until it reaches a terminal status
+func (c *CampaignsClient) waitCampaignDone(ctx context.Context, campaignID string, poll PollOptions) error {
+	var last CampaignProgress
+	for progress := range c.Watch(ctx, campaignID, CampaignWatchOptions{PollOptions: poll}) {
+		last = progress
+	}
+	if last.Done() {
+		return nil
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if last.Err != nil {
+		return last.Err
+	}
+	return fmt.Errorf("campaign %s did not finish", campaignID)
+}
+
+func (o SubjectTestOptions) campaign(name, subject, bookID string) CreateCampaignJSONBody {
+	sendNow := true
+	return CreateCampaignJSONBody{
+		Name:          name,
+		Subject:       subject,
+		From:          o.From,
+		ContactBookId: bookID,
+		Html:          o.Html,
+		Content:       o.Content,
+		PreviewText:   o.PreviewText,
+		SendNow:       &sendNow,
+	}
+}
+
+func (v SubjectVariantResult) metric(metric SubjectTestMetric) float64 {
+	if metric == SubjectTestClickRate {
+		return v.ClickRate
+	}
+	return v.OpenRate
+}
+
+// pickSubjectWinner returns the variant with the highest rate, preferring the earlier variant on ties
+func pickSubjectWinner(variants []SubjectVariantResult, metric SubjectTestMetric) int {
+	winner := 0
+	for i := range variants {
+		if variants[i].metric(metric) > variants[winner].metric(metric) {
+			winner = i
+		}
+	}
+	return winner
+}
+
+// splitSubjectTestCohorts shuffles the subscribed contacts with a seeded
+// source and splits them into equally sized cohorts plus a remainder
+func splitSubjectTestCohorts(contacts []Contact, variants int, fraction float64, seed int64) ([][]Contact, []Contact, error) {
+	eligible := make([]Contact, 0, len(contacts))
+	for _, contact := range contacts {
+		if contact.Subscribed == nil || *contact.Subscribed {
+			eligible = append(eligible, contact)
+		}
+	}
+	size := int(float64(len(eligible)) * fraction / float64(variants))
+	if size < 1 {
+		return nil, nil, fmt.Errorf("%d subscribed contacts are too few for %d cohorts at %.0f%%", len(eligible), variants, 100*fraction)
+	}
+
+	rand.New(rand.NewSource(seed)).Shuffle(len(eligible), func(i, j int) {
+		eligible[i], eligible[j] = eligible[j], eligible[i]
+	})
+	cohorts := make([][]Contact, variants)
+	for i := range cohorts {
+		cohorts[i] = eligible[i*size : (i+1)*size]
+	}
+	return cohorts, eligible[variants*size:], nil
+}
+
+// createCohortBook creates a contact book with the source book's properties
+// and fills it, returning the contacts that could not be added. It fails
+// when the book cannot be created or none of the contacts were added.
+func (c *CampaignsClient) createCohortBook(source *ContactBook, name string, contacts []Contact, concurrency int) (string, []SubjectTestFailure, error) {
+	payload := CreateContactBookJSONBody{Name: name}
+	if len(source.Properties) > 0 {
+		payload.Properties = &source.Properties
+	}
+	created, apiErr := c.client.ContactBooks.Create(payload)
+	if apiErr != nil {
+		return "", nil, apiErr
+	}
+
+	errs := make([]*APIError, len(contacts))
+	forEachConcurrent(len(contacts), concurrency, func(i int) {
+		_, errs[i] = c.client.Contacts.Create(created.ID, contactCreateBody(contacts[i]))
+	})
+	var failures []SubjectTestFailure
+	for i, err := range errs {
+		if err != nil {
+			failures = append(failures, SubjectTestFailure{Email: contacts[i].Email, BookID: created.ID, Err: err})
+		}
+	}
+	if len(contacts) > 0 && len(failures) == len(contacts) {
+		return created.ID, failures, fmt.Errorf("no contacts could be added to %s: %w", name, failures[0].Err)
+	}
+	return created.ID, failures, nil
+}
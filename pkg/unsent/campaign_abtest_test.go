@@ -0,0 +1,238 @@
+package unsent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func testContacts(n int) []Contact {
+	contacts := make([]Contact, n)
+	for i := range contacts {
+		contacts[i] = Contact{ID: fmt.Sprintf("c%d", i), Email: fmt.Sprintf("user%d@example.com", i)}
+	}
+	return contacts
+}
+
+func TestSplitSubjectTestCohorts(t *testing.T) {
+	contacts := testContacts(50)
+	contacts[0].Subscribed = boolRef(false)
+
+	a, restA, err := splitSubjectTestCohorts(append([]Contact(nil), contacts...), 2, 0.2, 42)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	b, _, _ := splitSubjectTestCohorts(append([]Contact(nil), contacts...), 2, 0.2, 42)
+	if len(a) != 2 || len(a[0]) != 4 || len(restA) != 41 {
+		t.Fatalf("unexpected split: %d cohorts of %d, remainder %d", len(a), len(a[0]), len(restA))
+	}
+	for i := range a[0] {
+		if a[0][i].ID != b[0][i].ID {
+			t.Fatal("expected the same seed to produce the same cohorts")
+		}
+		if a[0][i].ID == "c0" || a[1][i].ID == "c0" {
+			t.Error("expected unsubscribed contact to be excluded")
+		}
+	}
+
+	if _, _, err := splitSubjectTestCohorts(testContacts(3), 2, 0.2, 1); err == nil {
+		t.Error("expected error for too few contacts")
+	}
+}
+
+func TestCampaigns_RunSubjectTest(t *testing.T) {
+	var mu sync.Mutex
+	var campaigns []CreateCampaignJSONBody
+	books := 0
+	members := map[string]int{}
+	stats := map[string]string{
+		"camp_0": `{"id": "camp_0", "delivered": 10, "opened": 2}`,
+		"camp_1": `{"id": "camp_1", "delivered": 10, "opened": 5}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/v1/contactBooks/book1":
+			w.Write([]byte(`{"id": "book1", "properties": {"plan": "string"}}`))
+		case r.Method == "GET" && r.URL.Path == "/v1/contactBooks/book1/contacts":
+			if strings.HasPrefix(r.URL.Query().Get("page"), "1") {
+				body, _ := json.Marshal(testContacts(100))
+				w.Write(body)
+			} else {
+				w.Write([]byte(`[]`))
+			}
+		case r.Method == "POST" && r.URL.Path == "/v1/contactBooks":
+			w.Write([]byte(fmt.Sprintf(`{"id": "tmp_%d"}`, books)))
+			books++
+		case r.Method == "POST" && strings.HasSuffix(r.URL.Path, "/contacts"):
+			members[strings.Split(r.URL.Path, "/")[3]]++
+			w.Write([]byte(`{"contactId": "x"}`))
+		case r.Method == "POST" && r.URL.Path == "/v1/campaigns":
+			var body CreateCampaignJSONBody
+			json.NewDecoder(r.Body).Decode(&body)
+			w.Write([]byte(fmt.Sprintf(`{"id": "camp_%d"}`, len(campaigns))))
+			campaigns = append(campaigns, body)
+		case r.Method == "GET" && strings.HasPrefix(r.URL.Path, "/v1/campaigns/"):
+			w.Write([]byte(stats[strings.TrimPrefix(r.URL.Path, "/v1/campaigns/")]))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	client, _ := NewClient("key", WithBaseURL(server.URL))
+	report, err := client.Campaigns.RunSubjectTest(context.Background(), SubjectTestOptions{
+		Name:          "Launch",
+		ContactBookID: "book1",
+		From:          "news@example.com",
+		Subjects:      []string{"Plain subject", "Exciting subject!"},
+		Seed:          7,
+		Window:        time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if report.Winner != 1 || report.WinningSubject != "Exciting subject!" || report.Decision == "" {
+		t.Errorf("unexpected decision: %+v", report)
+	}
+	if report.RemainderRecipients != 80 || report.RemainderCampaignID != "camp_2" {
+		t.Errorf("unexpected remainder: %+v", report)
+	}
+	if members["tmp_0"] != 10 || members["tmp_1"] != 10 || members["tmp_2"] != 80 {
+		t.Errorf("unexpected cohort sizes: %v", members)
+	}
+	if len(campaigns) != 3 || campaigns[2].Subject != "Exciting subject!" || campaigns[2].ContactBookId != "tmp_2" {
+		t.Errorf("unexpected campaigns: %+v", campaigns)
+	}
+}
+
+func TestCampaigns_RunSubjectTestReportsPartialBooks(t *testing.T) {
+	var mu sync.Mutex
+	books := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/v1/contactBooks/book1":
+			w.Write([]byte(`{"id": "book1"}`))
+		case r.Method == "GET" && r.URL.Path == "/v1/contactBooks/book1/contacts":
+			if strings.HasPrefix(r.URL.Query().Get("page"), "1") {
+				body, _ := json.Marshal(testContacts(100))
+				w.Write(body)
+			} else {
+				w.Write([]byte(`[]`))
+			}
+		case r.Method == "POST" && r.URL.Path == "/v1/contactBooks":
+			w.Write([]byte(fmt.Sprintf(`{"id": "tmp_%d"}`, books)))
+			books++
+		case r.Method == "POST" && r.URL.Path == "/v1/contactBooks/tmp_1/contacts":
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error": {"code": "BAD_REQUEST", "message": "invalid contact"}}`))
+		case r.Method == "POST" && strings.HasSuffix(r.URL.Path, "/contacts"):
+			w.Write([]byte(`{"contactId": "x"}`))
+		case r.Method == "POST" && r.URL.Path == "/v1/campaigns":
+			w.Write([]byte(`{"id": "camp_0"}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	client, _ := NewClient("key", WithBaseURL(server.URL))
+	report, err := client.Campaigns.RunSubjectTest(context.Background(), SubjectTestOptions{
+		ContactBookID: "book1",
+		From:          "news@example.com",
+		Subjects:      []string{"A", "B"},
+		Seed:          7,
+		Window:        time.Millisecond,
+	})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if len(report.Variants) != 2 || report.Variants[1].BookID != "tmp_1" || report.Variants[1].CampaignID != "" {
+		t.Errorf("expected the partly filled book to be reported, got %+v", report.Variants)
+	}
+}
+
+func TestCampaigns_RunSubjectTestSkipsFailedContactsAndWaitsBeforeDeleting(t *testing.T) {
+	var mu sync.Mutex
+	books, created := 0, 0
+	polls := map[string]int{}
+	var events []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/v1/contactBooks/book1":
+			w.Write([]byte(`{"id": "book1"}`))
+		case r.Method == "GET" && r.URL.Path == "/v1/contactBooks/book1/contacts":
+			if strings.HasPrefix(r.URL.Query().Get("page"), "1") {
+				body, _ := json.Marshal(testContacts(100))
+				w.Write(body)
+			} else {
+				w.Write([]byte(`[]`))
+			}
+		case r.Method == "POST" && r.URL.Path == "/v1/contactBooks":
+			w.Write([]byte(fmt.Sprintf(`{"id": "tmp_%d"}`, books)))
+			books++
+		case r.Method == "POST" && strings.HasSuffix(r.URL.Path, "/contacts"):
+			var body CreateContactJSONBody
+			json.NewDecoder(r.Body).Decode(&body)
+			if body.Email == "user5@example.com" {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"error": {"code": "BAD_REQUEST", "message": "invalid contact"}}`))
+				return
+			}
+			w.Write([]byte(`{"contactId": "x"}`))
+		case r.Method == "POST" && r.URL.Path == "/v1/campaigns":
+			w.Write([]byte(fmt.Sprintf(`{"id": "camp_%d"}`, created)))
+			created++
+		case r.Method == "GET" && strings.HasPrefix(r.URL.Path, "/v1/campaigns/"):
+			id := strings.TrimPrefix(r.URL.Path, "/v1/campaigns/")
+			polls[id]++
+			status := CampaignStatusRunning
+			// the first poll reads the results, the next ones wait for the send to finish
+			if polls[id] > 2 {
+				status = CampaignStatusSent
+				events = append(events, id+" sent")
+			}
+			w.Write([]byte(`{"id": "` + id + `", "status": "` + status + `", "delivered": 10, "opened": 2}`))
+		case r.Method == "DELETE" && strings.HasPrefix(r.URL.Path, "/v1/contactBooks/"):
+			events = append(events, "delete "+strings.TrimPrefix(r.URL.Path, "/v1/contactBooks/"))
+			w.Write([]byte(`{"success": true}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	client, _ := NewClient("key", WithBaseURL(server.URL))
+	report, err := client.Campaigns.RunSubjectTest(context.Background(), SubjectTestOptions{
+		ContactBookID:   "book1",
+		From:            "news@example.com",
+		Subjects:        []string{"A", "B"},
+		Seed:            7,
+		Window:          time.Millisecond,
+		DeleteTestBooks: true,
+		PollOptions:     PollOptions{Interval: time.Millisecond},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(report.Failures) != 1 || report.Failures[0].Email != "user5@example.com" {
+		t.Errorf("expected the failed contact to be recorded, got %+v", report.Failures)
+	}
+	if recipients := report.Variants[0].Recipients + report.Variants[1].Recipients + report.RemainderRecipients; recipients != 99 {
+		t.Errorf("expected 99 recipients without the failed contact, got %d", recipients)
+	}
+	if got := strings.Join(events, ","); got != "camp_0 sent,delete tmp_0,camp_1 sent,delete tmp_1" {
+		t.Errorf("expected each book deleted after its campaign finished, got %s", got)
+	}
+}
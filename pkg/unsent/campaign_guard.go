@@ -0,0 +1,147 @@
+package unsent
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// CampaignGuardThresholds are the limits that make a guard pause a campaign.
+// Absolute limits trip when a counter exceeds them; rate limits are a fraction
+// of sent messages and only apply once MinSent messages have been sent.
+// Zero values disable a limit.
+type CampaignGuardThresholds struct {
+	MaxBounced      int
+	MaxHardBounced  int
+	MaxComplained   int
+	MaxUnsubscribed int
+
+	BounceRate      float64
+	HardBounceRate  float64
+	ComplaintRate   float64
+	UnsubscribeRate float64
+	MinSent         int
+}
+
+// CampaignGuardTrip records why a guard paused a campaign
+type CampaignGuardTrip struct {
+	CampaignID string
+	// Metric is the counter that crossed its threshold, e.g. "hardBounced" or "complaintRate"
+	Metric    string
+	Value     float64
+	Threshold float64
+	Reason    string
+	Progress  CampaignProgress
+	PausedAt  time.Time
+	// PauseErr is set when the pause call failed
+	PauseErr *APIError
+}
+
+// CampaignGuardOptions configures CampaignsClient.Guard
+type CampaignGuardOptions struct {
+	CampaignWatchOptions
+	Thresholds CampaignGuardThresholds
+	// OnTrip is called after the guard attempted to pause the campaign
+	OnTrip func(trip CampaignGuardTrip)
+}
+
+// CampaignGuardResult is returned when a guard stops watching
+type CampaignGuardResult struct {
+	// Trip is set when a threshold was crossed
+	Trip *CampaignGuardTrip
+	// Last is the final snapshot observed
+	Last CampaignProgress
+}
+
+// Guard watches a running campaign and pauses it as soon as one of the
+// thresholds is crossed. Thresholds are only checked while the campaign is
+// RUNNING, so a scheduled or already paused campaign is left alone. It blocks until the campaign finishes, the guard
+// trips, polling fails MaxConsecutiveErrors times in a row or the context is
+// cancelled. In the last two cases the polling or context error is returned
+// alongside the partial result.
+func (c *CampaignsClient) Guard(ctx context.Context, campaignID string, opts CampaignGuardOptions) (*CampaignGuardResult, error) {
+	watchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	result := &CampaignGuardResult{}
+	for progress := range c.Watch(watchCtx, campaignID, opts.CampaignWatchOptions) {
+		result.Last = progress
+		if progress.Err != nil || progress.Campaign.Status != CampaignStatusRunning {
+			continue
+		}
+		metric, value, threshold, ok := opts.Thresholds.check(progress.Campaign)
+		if !ok {
+			continue
+		}
+		cancel()
+
+		trip := CampaignGuardTrip{
+			CampaignID: campaignID,
+			Metric:     metric,
+			Value:      value,
+			Threshold:  threshold,
+			Reason:     fmt.Sprintf("%s %v exceeded threshold %v after %d sent", metric, value, threshold, progress.Campaign.Sent),
+			Progress:   progress,
+		}
+		if _, err := c.Pause(campaignID); err != nil {
+			trip.PauseErr = err
+		} else {
+			trip.PausedAt = time.Now()
+		}
+		result.Trip = &trip
+		if opts.OnTrip != nil {
+			opts.OnTrip(trip)
+		}
+		if trip.PauseErr != nil {
+			return result, trip.PauseErr
+		}
+		return result, nil
+	}
+	if err := ctx.Err(); err != nil {
+		return result, err
+	}
+	// Watch gave up after MaxConsecutiveErrors failed polls
+	if result.Last.Err != nil {
+		return result, result.Last.Err
+	}
+	return result, nil
+}
+
+// check returns the first threshold crossed by the campaign counters
+func (t CampaignGuardThresholds) check(d CampaignDetail) (string, float64, float64, bool) {
+	absolute := []struct {
+		metric string
+		value  int
+		limit  int
+	}{
+		{"bounced", d.Bounced, t.MaxBounced},
+		{"hardBounced", d.HardBounced, t.MaxHardBounced},
+		{"complained", d.Complained, t.MaxComplained},
+		{"unsubscribed", d.Unsubscribed, t.MaxUnsubscribed},
+	}
+	for _, a := range absolute {
+		if a.limit > 0 && a.value > a.limit {
+			return a.metric, float64(a.value), float64(a.limit), true
+		}
+	}
+
+	if d.Sent == 0 || d.Sent < t.MinSent {
+		return "", 0, 0, false
+	}
+	rates := []struct {
+		metric string
+		value  float64
+		limit  float64
+	}{
+		{"bounceRate", ratio(d.Bounced, d.Sent), t.BounceRate},
+		{"hardBounceRate", ratio(d.HardBounced, d.Sent), t.HardBounceRate},
+		{"complaintRate", ratio(d.Complained, d.Sent), t.ComplaintRate},
+		{"unsubscribeRate", ratio(d.Unsubscribed, d.Sent), t.UnsubscribeRate},
+	}
+	for _, r := range rates {
+		if r.limit > 0 && r.value > r.limit {
+			return r.metric, r.value, r.limit, true
+		}
+	}
+	return "", 0, 0, false
+}
@@ -0,0 +1,121 @@
+package unsent
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCampaigns_GuardPausesOnComplaintRate(t *testing.T) {
+	responses := []string{
+		`{"id": "camp1", "status": "RUNNING", "total": 1000, "sent": 50, "complained": 2}`,
+		`{"id": "camp1", "status": "RUNNING", "total": 1000, "sent": 200, "complained": 3}`,
+		`{"id": "camp1", "status": "RUNNING", "total": 1000, "sent": 400, "complained": 9}`,
+	}
+	var polls int32
+	var paused bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" && r.URL.Path == "/v1/campaigns/camp1/pause" {
+			paused = true
+			w.Write([]byte(`{"id": "camp1", "status": "PAUSED"}`))
+			return
+		}
+		i := int(atomic.AddInt32(&polls, 1)) - 1
+		if i >= len(responses) {
+			i = len(responses) - 1
+		}
+		w.Write([]byte(responses[i]))
+	}))
+	defer server.Close()
+
+	client, _ := NewClient("key", WithBaseURL(server.URL))
+
+	var notified *CampaignGuardTrip
+	result, err := client.Campaigns.Guard(context.Background(), "camp1", CampaignGuardOptions{
+		CampaignWatchOptions: CampaignWatchOptions{PollOptions: PollOptions{Interval: time.Millisecond}},
+		Thresholds:           CampaignGuardThresholds{ComplaintRate: 0.02, MinSent: 100, MaxBounced: 50},
+		OnTrip:               func(trip CampaignGuardTrip) { notified = &trip },
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !paused {
+		t.Fatal("expected campaign to be paused")
+	}
+	if result.Trip == nil || result.Trip.Metric != "complaintRate" || result.Trip.Progress.Campaign.Sent != 400 {
+		t.Fatalf("unexpected trip: %+v", result.Trip)
+	}
+	if result.Trip.PausedAt.IsZero() || notified == nil || notified.Reason == "" {
+		t.Errorf("expected trip to be recorded and notified, got %+v", notified)
+	}
+}
+
+func TestCampaignGuardThresholds_Absolute(t *testing.T) {
+	th := CampaignGuardThresholds{MaxHardBounced: 5, BounceRate: 0.5, MinSent: 1000}
+	if _, _, _, ok := th.check(CampaignDetail{Sent: 10, Bounced: 9, HardBounced: 5}); ok {
+		t.Error("expected no trip below MinSent and at the absolute limit")
+	}
+	metric, value, _, ok := th.check(CampaignDetail{Sent: 10, HardBounced: 6})
+	if !ok || metric != "hardBounced" || value != 6 {
+		t.Errorf("expected hardBounced trip, got %s %v %v", metric, value, ok)
+	}
+}
+
+func TestCampaigns_GuardReturnsWatchError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte(`{"error": {"code": "SERVICE_UNAVAILABLE", "message": "try again"}}`))
+	}))
+	defer server.Close()
+
+	client, _ := NewClient("key", WithBaseURL(server.URL))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := client.Campaigns.Guard(ctx, "camp1", CampaignGuardOptions{
+		CampaignWatchOptions: CampaignWatchOptions{
+			PollOptions:          PollOptions{Interval: time.Millisecond},
+			MaxConsecutiveErrors: 2,
+		},
+		Thresholds: CampaignGuardThresholds{MaxBounced: 10},
+	})
+	apiErr, ok := err.(*APIError)
+	if !ok || apiErr.Code != "SERVICE_UNAVAILABLE" {
+		t.Fatalf("expected the last polling error, got %v", err)
+	}
+	if result.Trip != nil || ctx.Err() != nil {
+		t.Errorf("expected the guard to stop without tripping, got %+v", result)
+	}
+}
+
+func TestCampaigns_GuardIgnoresCampaignsNotRunning(t *testing.T) {
+	responses := []string{
+		`{"id": "camp1", "status": "PAUSED", "total": 1000, "sent": 400, "bounced": 90}`,
+		`{"id": "camp1", "status": "SCHEDULED", "total": 1000, "sent": 400, "bounced": 90}`,
+		`{"id": "camp1", "status": "CANCELLED", "total": 1000, "sent": 400, "bounced": 90}`,
+	}
+	var polls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" {
+			t.Errorf("unexpected %s %s", r.Method, r.URL.Path)
+		}
+		i := int(atomic.AddInt32(&polls, 1)) - 1
+		if i >= len(responses) {
+			i = len(responses) - 1
+		}
+		w.Write([]byte(responses[i]))
+	}))
+	defer server.Close()
+
+	client, _ := NewClient("key", WithBaseURL(server.URL))
+	result, err := client.Campaigns.Guard(context.Background(), "camp1", CampaignGuardOptions{
+		CampaignWatchOptions: CampaignWatchOptions{PollOptions: PollOptions{Interval: time.Millisecond}},
+		Thresholds:           CampaignGuardThresholds{MaxBounced: 50},
+	})
+	if err != nil || result.Trip != nil {
+		t.Errorf("expected no trip outside RUNNING, got %+v, %v", result.Trip, err)
+	}
+}
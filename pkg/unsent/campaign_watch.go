@@ -0,0 +1,157 @@
+package unsent
+
+import (
+	"context"
+	"time"
+)
+
+// CampaignProgress is a snapshot of a campaign emitted by CampaignsClient.Watch
+type CampaignProgress struct {
+	Campaign CampaignDetail
+	At       time.Time
+	// Processed is the number of messages sent or failed so far
+	Processed int
+	// Percent of the campaign total that has been processed
+	Percent float64
+	// SendRate is the smoothed number of messages processed per second
+	SendRate      float64
+	DeliveryRate  float64
+	OpenRate      float64
+	ClickRate     float64
+	BounceRate    float64
+	ComplaintRate float64
+	// ETA is the estimated time until every message is processed, zero when unknown
+	ETA time.Duration
+	// Err is set when the latest poll failed; Campaign then holds the last known state
+	Err *APIError
+}
+
+// Done reports whether the campaign reached a terminal status
+func (p CampaignProgress) Done() bool {
+	return isTerminalCampaignStatus(p.Campaign.Status)
+}
+
+// CampaignWatchOptions configures CampaignsClient.Watch
+type CampaignWatchOptions struct {
+	PollOptions
+	// OnStatusChange is called when the campaign moves between statuses,
+	// e.g. SCHEDULED -> RUNNING -> PAUSED -> SENT
+	OnStatusChange func(from, to string, progress CampaignProgress)
+	// OnComplete is called once when the campaign reaches SENT, FAILED or CANCELLED
+	OnComplete func(progress CampaignProgress)
+	// MaxConsecutiveErrors stops the watch after this many failed polls in a row, defaults to 5
+	MaxConsecutiveErrors int
+}
+
+// Watch polls a campaign until it reaches a terminal status, the context is
+// cancelled or polling fails MaxConsecutiveErrors times in a row. A snapshot
+// is sent on the returned channel after every poll; the channel is closed
+// when watching stops. The poll interval resets whenever the campaign makes
+// progress and backs off while it is idle (scheduled, paused) or failing.
+func (c *CampaignsClient) Watch(ctx context.Context, campaignID string, opts CampaignWatchOptions) <-chan CampaignProgress {
+	if opts.MaxConsecutiveErrors <= 0 {
+		opts.MaxConsecutiveErrors = 5
+	}
+	updates := make(chan CampaignProgress, 1)
+
+	go func() {
+		defer close(updates)
+		wait := newBackoff(opts.PollOptions)
+		var last *CampaignProgress
+		failures := 0
+
+		for {
+			detail, apiErr := c.Get(campaignID)
+			var progress CampaignProgress
+			if apiErr != nil {
+				failures++
+				if last != nil {
+					progress = *last
+				}
+				progress.At = time.Now()
+				progress.Err = apiErr
+			} else {
+				failures = 0
+				progress = newCampaignProgress(*detail, time.Now(), last)
+			}
+
+			if apiErr == nil && last != nil && last.Campaign.Status != progress.Campaign.Status && opts.OnStatusChange != nil {
+				opts.OnStatusChange(last.Campaign.Status, progress.Campaign.Status, progress)
+			}
+
+			select {
+			case updates <- progress:
+			case <-ctx.Done():
+				return
+			}
+
+			if apiErr == nil {
+				if progress.Done() {
+					if opts.OnComplete != nil {
+						opts.OnComplete(progress)
+					}
+					return
+				}
+				if last == nil || progress.Processed != last.Processed || progress.Campaign.Status != last.Campaign.Status {
+					wait.reset()
+				}
+				last = &progress
+			} else if failures >= opts.MaxConsecutiveErrors {
+				return
+			}
+
+			if sleepContext(ctx, wait.next()) != nil {
+				return
+			}
+		}
+	}()
+
+	return updates
+}
+
+// newCampaignProgress derives rates and the ETA for a snapshot from the previous one
+func newCampaignProgress(detail CampaignDetail, at time.Time, previous *CampaignProgress) CampaignProgress {
+	p := CampaignProgress{
+		Campaign:      detail,
+		At:            at,
+		Processed:     detail.Sent + detail.Failed,
+		DeliveryRate:  ratio(detail.Delivered, detail.Sent),
+		OpenRate:      ratio(detail.Opened, detail.Delivered),
+		ClickRate:     ratio(detail.Clicked, detail.Delivered),
+		BounceRate:    ratio(detail.Bounced, detail.Sent),
+		ComplaintRate: ratio(detail.Complained, detail.Delivered),
+	}
+	p.Percent = 100 * ratio(p.Processed, detail.Total)
+
+	if previous != nil {
+		p.SendRate = previous.SendRate
+		if elapsed := at.Sub(previous.At).Seconds(); elapsed > 0 {
+			instant := float64(p.Processed-previous.Processed) / elapsed
+			if instant < 0 {
+				instant = 0
+			}
+			if p.SendRate == 0 {
+				p.SendRate = instant
+			} else {
+				// Exponential smoothing keeps the ETA stable across batch windows
+				p.SendRate = 0.3*instant + 0.7*p.SendRate
+			}
+		}
+	}
+	if remaining := detail.Total - p.Processed; remaining > 0 && p.SendRate > 0 && detail.Status == CampaignStatusRunning {
+		p.ETA = time.Duration(float64(remaining) / p.SendRate * float64(time.Second))
+	}
+	return p
+}
+
+func isTerminalCampaignStatus(status string) bool {
+	return status == CampaignStatusSent || status == CampaignStatusFailed || status == CampaignStatusCancelled
+}
+
+// ratio returns part/whole, or zero when whole is zero
+func ratio(part, whole int) float64 {
+	if whole == 0 {
+		return 0
+	}
+	return float64(part) / float64(whole)
+}
@@ -0,0 +1,127 @@
+package unsent
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCampaigns_Watch(t *testing.T) {
+	responses := []string{
+		`{"id": "camp1", "status": "SCHEDULED", "total": 100}`,
+		`{"id": "camp1", "status": "RUNNING", "total": 100, "sent": 40, "delivered": 38}`,
+		`{"id": "camp1", "status": "RUNNING", "total": 100, "sent": 80, "delivered": 76, "opened": 19}`,
+		`{"id": "camp1", "status": "SENT", "total": 100, "sent": 98, "failed": 2, "delivered": 95, "bounced": 3}`,
+	}
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/campaigns/camp1" {
+			t.Errorf("expected /v1/campaigns/camp1, got %s", r.URL.Path)
+		}
+		i := int(atomic.AddInt32(&calls, 1)) - 1
+		if i == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if i > 1 {
+			i--
+		}
+		w.Write([]byte(responses[i]))
+	}))
+	defer server.Close()
+
+	client, _ := NewClient("key", WithBaseURL(server.URL))
+
+	var transitions []string
+	var completed *CampaignProgress
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	updates := client.Campaigns.Watch(ctx, "camp1", CampaignWatchOptions{
+		PollOptions: PollOptions{Interval: time.Millisecond},
+		OnStatusChange: func(from, to string, _ CampaignProgress) {
+			transitions = append(transitions, from+"->"+to)
+		},
+		OnComplete: func(p CampaignProgress) { completed = &p },
+	})
+
+	var snapshots []CampaignProgress
+	for p := range updates {
+		snapshots = append(snapshots, p)
+	}
+
+	if len(snapshots) != 5 {
+		t.Fatalf("expected 5 snapshots, got %d", len(snapshots))
+	}
+	if snapshots[1].Err == nil || snapshots[1].Campaign.Status != CampaignStatusScheduled {
+		t.Errorf("expected failed poll to carry last known state, got %+v", snapshots[1])
+	}
+	if snapshots[3].Percent != 80 || snapshots[3].SendRate <= 0 || snapshots[3].ETA <= 0 {
+		t.Errorf("unexpected running snapshot: %+v", snapshots[3])
+	}
+	if got := []string{"SCHEDULED->RUNNING", "RUNNING->SENT"}; len(transitions) != 2 || transitions[0] != got[0] || transitions[1] != got[1] {
+		t.Errorf("unexpected transitions: %v", transitions)
+	}
+	if completed == nil || completed.Processed != 100 || completed.BounceRate != 3.0/98 {
+		t.Errorf("unexpected completion: %+v", completed)
+	}
+}
+
+func TestCampaigns_WatchStopsOnContext(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id": "camp1", "status": "PAUSED"}`))
+	}))
+	defer server.Close()
+
+	client, _ := NewClient("key", WithBaseURL(server.URL))
+	ctx, cancel := context.WithCancel(context.Background())
+	updates := client.Campaigns.Watch(ctx, "camp1", CampaignWatchOptions{PollOptions: PollOptions{Interval: time.Millisecond}})
+
+	<-updates
+	cancel()
+	for range updates {
+	}
+}
+
+func TestCampaigns_WatchEndsOnCancelled(t *testing.T) {
+	responses := []string{
+		`{"id": "camp1", "status": "RUNNING", "total": 100, "sent": 40}`,
+		`{"id": "camp1", "status": "CANCELLED", "total": 100, "sent": 40}`,
+	}
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		i := int(atomic.AddInt32(&calls, 1)) - 1
+		if i >= len(responses) {
+			t.Errorf("expected polling to stop after CANCELLED, got request %d", i+1)
+			i = len(responses) - 1
+		}
+		w.Write([]byte(responses[i]))
+	}))
+	defer server.Close()
+
+	client, _ := NewClient("key", WithBaseURL(server.URL))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var completed *CampaignProgress
+	updates := client.Campaigns.Watch(ctx, "camp1", CampaignWatchOptions{
+		PollOptions: PollOptions{Interval: time.Millisecond},
+		OnComplete:  func(p CampaignProgress) { completed = &p },
+	})
+	var last CampaignProgress
+	for p := range updates {
+		last = p
+	}
+	if ctx.Err() != nil {
+		t.Fatalf("expected the watch to end before the context, got %v", ctx.Err())
+	}
+	if !last.Done() || last.Campaign.Status != CampaignStatusCancelled {
+		t.Errorf("expected the last snapshot to be CANCELLED, got %+v", last)
+	}
+	if completed == nil || completed.Campaign.Status != CampaignStatusCancelled {
+		t.Errorf("expected OnComplete for CANCELLED, got %+v", completed)
+	}
+}
@@ -1,6 +1,94 @@
 package unsent
 
-import "fmt"
+import (
+	"fmt"
+	"net/mail"
+)
+
+// Campaign statuses reported by the API
+const (
+	CampaignStatusDraft     = "DRAFT"
+	CampaignStatusScheduled = "SCHEDULED"
+	CampaignStatusRunning   = "RUNNING"
+	CampaignStatusPaused    = "PAUSED"
+	CampaignStatusSent      = "SENT"
+	CampaignStatusFailed    = "FAILED"
+	CampaignStatusCancelled = "CANCELLED"
+)
+
+// CampaignAction is an operation that changes a campaign
+type CampaignAction string
+
+const (
+	CampaignActionUpdate    CampaignAction = "update"
+	CampaignActionDelete    CampaignAction = "delete"
+	CampaignActionSchedule  CampaignAction = "schedule"
+	CampaignActionPause     CampaignAction = "pause"
+	CampaignActionResume    CampaignAction = "resume"
+	CampaignActionCancel    CampaignAction = "cancel"
+	CampaignActionTestSend  CampaignAction = "testSend"
+	CampaignActionDuplicate CampaignAction = "duplicate"
+)
+
+// campaignTransitions lists the statuses each action is legal from.
+// Duplicate is legal from any status and is not listed.
+var campaignTransitions = map[CampaignAction][]string{
+	CampaignActionUpdate:   {CampaignStatusDraft, CampaignStatusScheduled, CampaignStatusPaused},
+	CampaignActionDelete:   {CampaignStatusDraft, CampaignStatusScheduled, CampaignStatusPaused, CampaignStatusSent, CampaignStatusFailed, CampaignStatusCancelled},
+	CampaignActionSchedule: {CampaignStatusDraft, CampaignStatusScheduled},
+	CampaignActionPause:    {CampaignStatusScheduled, CampaignStatusRunning},
+	CampaignActionResume:   {CampaignStatusPaused},
+	CampaignActionCancel:   {CampaignStatusScheduled, CampaignStatusRunning, CampaignStatusPaused},
+	CampaignActionTestSend: {CampaignStatusDraft, CampaignStatusScheduled, CampaignStatusPaused},
+}
+
+// ValidateCampaignTransition reports whether action is legal for a campaign in the given status
+func ValidateCampaignTransition(status string, action CampaignAction) *APIError {
+	if action == CampaignActionDuplicate {
+		return nil
+	}
+	allowed, ok := campaignTransitions[action]
+	if !ok {
+		return &APIError{Code: "INVALID_ACTION", Message: fmt.Sprintf("unknown campaign action %q", action)}
+	}
+	for _, s := range allowed {
+		if s == status {
+			return nil
+		}
+	}
+	return &APIError{Code: "INVALID_TRANSITION", Message: fmt.Sprintf("cannot %s a campaign in status %s", action, status)}
+}
+
+// UpdateCampaignJSONBody defines the fields that can be changed on a campaign
+type UpdateCampaignJSONBody struct {
+	Name          *string   `json:"name,omitempty"`
+	Subject       *string   `json:"subject,omitempty"`
+	PreviewText   *string   `json:"previewText,omitempty"`
+	From          *string   `json:"from,omitempty"`
+	ContactBookId *string   `json:"contactBookId,omitempty"`
+	Html          *string   `json:"html,omitempty"`
+	Content       *string   `json:"content,omitempty"`
+	ReplyTo       *[]string `json:"replyTo,omitempty"`
+	Cc            *[]string `json:"cc,omitempty"`
+	Bcc           *[]string `json:"bcc,omitempty"`
+	BatchSize     *int      `json:"batchSize,omitempty"`
+}
+
+// TestSendCampaignJSONBody defines the seed addresses a campaign test is sent to
+type TestSendCampaignJSONBody struct {
+	Emails []string `json:"emails"`
+}
+
+// CampaignDeleteResponse represents the response from deleting a campaign
+type CampaignDeleteResponse struct {
+	ID      string `json:"id"`
+	Deleted bool   `json:"deleted"`
+}
+
+// CampaignTestSendResponse represents the response from test-sending a campaign
+type CampaignTestSendResponse struct {
+	EmailIDs []string `json:"emailIds"`
+}
 
 // CampaignsClient handles campaign-related API operations
 type CampaignsClient struct {
@@ -17,9 +105,71 @@ func (c *CampaignsClient) Create(payload CreateCampaignJSONBody) (*CampaignCreat
 	return Post[CampaignCreateResponse](c.client, "/campaigns", payload)
 }
 
-// Get retrieves a campaign by ID
-func (c *CampaignsClient) Get(campaignID string) (*Campaign, *APIError) {
-	return Get[Campaign](c.client, fmt.Sprintf("/campaigns/%s", campaignID))
+// Get retrieves a campaign by ID, including its delivery counters
+func (c *CampaignsClient) Get(campaignID string) (*CampaignDetail, *APIError) {
+	return Get[CampaignDetail](c.client, fmt.Sprintf("/campaigns/%s", campaignID))
+}
+
+// Update updates a draft, scheduled or paused campaign. The campaign is
+// retrieved first and an INVALID_TRANSITION error returned for any other status.
+func (c *CampaignsClient) Update(campaignID string, payload UpdateCampaignJSONBody) (*CampaignDetail, *APIError) {
+	if _, err := c.CheckTransition(campaignID, CampaignActionUpdate); err != nil {
+		return nil, err
+	}
+	return Patch[CampaignDetail](c.client, fmt.Sprintf("/campaigns/%s", campaignID), payload)
+}
+
+// Delete deletes a campaign that is not running. The campaign is retrieved
+// first and an INVALID_TRANSITION error returned while it is running.
+func (c *CampaignsClient) Delete(campaignID string) (*CampaignDeleteResponse, *APIError) {
+	if _, err := c.CheckTransition(campaignID, CampaignActionDelete); err != nil {
+		return nil, err
+	}
+	return Delete[CampaignDeleteResponse](c.client, fmt.Sprintf("/campaigns/%s", campaignID), nil)
+}
+
+// Cancel stops a scheduled, running or paused campaign for good. The campaign
+// is retrieved first and an INVALID_TRANSITION error returned for any other status.
+func (c *CampaignsClient) Cancel(campaignID string) (*CampaignActionResponse, *APIError) {
+	if _, err := c.CheckTransition(campaignID, CampaignActionCancel); err != nil {
+		return nil, err
+	}
+	return Post[CampaignActionResponse](c.client, fmt.Sprintf("/campaigns/%s/cancel", campaignID), map[string]interface{}{})
+}
+
+// Duplicate creates a new draft campaign with the same content and settings
+func (c *CampaignsClient) Duplicate(campaignID string) (*CampaignCreateResponse, *APIError) {
+	return Post[CampaignCreateResponse](c.client, fmt.Sprintf("/campaigns/%s/duplicate", campaignID), map[string]interface{}{})
+}
+
+// TestSend sends a draft, scheduled or paused campaign to a handful of seed
+// addresses without touching its contact book. Each address must be a bare
+// email address, and the campaign status is checked as in Update.
+func (c *CampaignsClient) TestSend(campaignID string, emails ...string) (*CampaignTestSendResponse, *APIError) {
+	if len(emails) == 0 {
+		return nil, &APIError{Code: "BAD_REQUEST", Message: "at least one test recipient is required"}
+	}
+	for _, email := range emails {
+		if addr, err := mail.ParseAddress(email); err != nil || addr.Address != email {
+			return nil, &APIError{Code: "BAD_REQUEST", Message: fmt.Sprintf("invalid test recipient %q", email)}
+		}
+	}
+	if _, err := c.CheckTransition(campaignID, CampaignActionTestSend); err != nil {
+		return nil, err
+	}
+	return Post[CampaignTestSendResponse](c.client, fmt.Sprintf("/campaigns/%s/test", campaignID), TestSendCampaignJSONBody{Emails: emails})
+}
+
+// CheckTransition retrieves a campaign and validates that action is legal for its current status
+func (c *CampaignsClient) CheckTransition(campaignID string, action CampaignAction) (*CampaignDetail, *APIError) {
+	campaign, err := c.Get(campaignID)
+	if err != nil {
+		return nil, err
+	}
+	if err := ValidateCampaignTransition(campaign.Status, action); err != nil {
+		return campaign, err
+	}
+	return campaign, nil
 }
 
 // Schedule schedules a campaign
@@ -149,3 +149,113 @@ func TestCampaigns_Resume(t *testing.T) {
 		t.Errorf("expected camp1, got %s", resp.ID)
 	}
 }
+
+func TestCampaigns_GetDetail(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"id": "camp1", "status": "SENT", "sent": 10, "delivered": 9, "hardBounced": 1}`))
+	}))
+	defer server.Close()
+	client, _ := NewClient("key", WithBaseURL(server.URL))
+	resp, err := client.Campaigns.Get("camp1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Delivered != 9 || resp.HardBounced != 1 {
+		t.Errorf("expected delivery counters, got %+v", resp)
+	}
+}
+
+func TestCampaigns_Lifecycle(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		if r.Method == "GET" {
+			w.Write([]byte(`{"id": "camp1", "status": "PAUSED"}`))
+			return
+		}
+		if r.URL.Path == "/v1/campaigns/camp1/test" {
+			var body TestSendCampaignJSONBody
+			json.NewDecoder(r.Body).Decode(&body)
+			if len(body.Emails) != 2 {
+				t.Errorf("expected 2 test recipients, got %v", body.Emails)
+			}
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"id": "camp2", "deleted": true, "status": "CANCELLED"}`))
+	}))
+	defer server.Close()
+	client, _ := NewClient("key", WithBaseURL(server.URL))
+
+	subject := "New subject"
+	if _, err := client.Campaigns.Update("camp1", UpdateCampaignJSONBody{Subject: &subject}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := client.Campaigns.Cancel("camp1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	dup, err := client.Campaigns.Duplicate("camp1")
+	if err != nil || dup.ID != "camp2" {
+		t.Fatalf("unexpected duplicate: %+v %v", dup, err)
+	}
+	if _, err := client.Campaigns.TestSend("camp1", "seed1@example.com", "seed2@example.com"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	del, err := client.Campaigns.Delete("camp1")
+	if err != nil || !del.Deleted {
+		t.Fatalf("unexpected delete: %+v %v", del, err)
+	}
+	if _, err := client.Campaigns.TestSend("camp1"); err == nil {
+		t.Error("expected error without test recipients")
+	}
+	for _, invalid := range []string{"@", "a@", "Seed <seed@example.com>", "seed@example.com, other@example.com"} {
+		if _, err := client.Campaigns.TestSend("camp1", invalid); err == nil || err.Code != "BAD_REQUEST" {
+			t.Errorf("expected %q to be rejected, got %v", invalid, err)
+		}
+	}
+
+	expected := []string{
+		"GET /v1/campaigns/camp1",
+		"PATCH /v1/campaigns/camp1",
+		"GET /v1/campaigns/camp1",
+		"POST /v1/campaigns/camp1/cancel",
+		"POST /v1/campaigns/camp1/duplicate",
+		"GET /v1/campaigns/camp1",
+		"POST /v1/campaigns/camp1/test",
+		"GET /v1/campaigns/camp1",
+		"DELETE /v1/campaigns/camp1",
+	}
+	if len(requests) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, requests)
+	}
+	for i := range expected {
+		if requests[i] != expected[i] {
+			t.Errorf("expected %s, got %s", expected[i], requests[i])
+		}
+	}
+}
+
+func TestCampaigns_CheckTransition(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"id": "camp1", "status": "SENT"}`))
+	}))
+	defer server.Close()
+	client, _ := NewClient("key", WithBaseURL(server.URL))
+
+	if _, err := client.Campaigns.CheckTransition("camp1", CampaignActionPause); err == nil || err.Code != "INVALID_TRANSITION" {
+		t.Errorf("expected INVALID_TRANSITION, got %v", err)
+	}
+	if _, err := client.Campaigns.CheckTransition("camp1", CampaignActionDuplicate); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := client.Campaigns.Cancel("camp1"); err == nil || err.Code != "INVALID_TRANSITION" {
+		t.Errorf("expected Cancel to refuse a sent campaign, got %v", err)
+	}
+	if _, err := client.Campaigns.Update("camp1", UpdateCampaignJSONBody{}); err == nil || err.Code != "INVALID_TRANSITION" {
+		t.Errorf("expected Update to refuse a sent campaign, got %v", err)
+	}
+	if err := ValidateCampaignTransition(CampaignStatusPaused, CampaignActionResume); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"os"
 )
@@ -21,6 +22,9 @@ type Client struct {
 	RaiseOnError bool
 	HTTPClient   *http.Client
 
+	logger     *slog.Logger
+	logOptions *LogOptions
+
 	// Resource clients
 	Emails       *EmailsClient
 	Contacts     *ContactsClient
@@ -67,28 +71,32 @@ func NewClient(key string, options ...ClientOption) (*Client, error) {
 		opt(client)
 	}
 
-	// Initialize resource clients
-	client.Emails = &EmailsClient{client: client}
-	client.Contacts = &ContactsClient{client: client}
-	client.Campaigns = &CampaignsClient{client: client}
-	client.Domains = &DomainsClient{client: client}
-	client.Analytics = &AnalyticsClient{client: client}
-	client.ApiKeys = &ApiKeysClient{client: client}
-	client.ContactBooks = &ContactBooksClient{client: client}
-	client.Settings = &SettingsClient{client: client}
-	client.Suppressions = &SuppressionsClient{client: client}
-	client.Templates = &TemplatesClient{client: client}
-	client.Webhooks = &WebhooksClient{client: client}
-	client.System = &SystemClient{client: client}
-	client.Events = &EventsClient{client: client}
-	client.Metrics = &MetricsClient{client: client}
-	client.Stats = &StatsClient{client: client}
-	client.Activity = &ActivityClient{client: client}
-	client.Teams = &TeamsClient{client: client}
+	client.initResources()
 
 	return client, nil
 }
 
+// initResources points the resource clients at c
+func (c *Client) initResources() {
+	c.Emails = &EmailsClient{client: c}
+	c.Contacts = &ContactsClient{client: c}
+	c.Campaigns = &CampaignsClient{client: c}
+	c.Domains = &DomainsClient{client: c}
+	c.Analytics = &AnalyticsClient{client: c}
+	c.ApiKeys = &ApiKeysClient{client: c}
+	c.ContactBooks = &ContactBooksClient{client: c}
+	c.Settings = &SettingsClient{client: c}
+	c.Suppressions = &SuppressionsClient{client: c}
+	c.Templates = &TemplatesClient{client: c}
+	c.Webhooks = &WebhooksClient{client: c}
+	c.System = &SystemClient{client: c}
+	c.Events = &EventsClient{client: c}
+	c.Metrics = &MetricsClient{client: c}
+	c.Stats = &StatsClient{client: c}
+	c.Activity = &ActivityClient{client: c}
+	c.Teams = &TeamsClient{client: c}
+}
+
 // ClientOption is a function that configures a Client
 type ClientOption func(*Client)
 
@@ -152,7 +160,7 @@ func request[T any](c *Client, method, path string, body interface{}, opts ...Re
 		opt(req)
 	}
 
-	resp, err := c.HTTPClient.Do(req)
+	resp, err := c.send(req, body)
 	if err != nil {
 		return nil, &APIError{Code: "INTERNAL_ERROR", Message: err.Error()}
 	}
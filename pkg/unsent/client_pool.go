@@ -0,0 +1,339 @@
+package unsent
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// KeyProvider resolves the API key of a tenant, e.g. from a database or a
+// secret manager
+type KeyProvider interface {
+	APIKey(ctx context.Context, tenant string) (string, error)
+}
+
+// KeyProviderFunc adapts a function to KeyProvider
+type KeyProviderFunc func(ctx context.Context, tenant string) (string, error)
+
+// APIKey implements KeyProvider
+func (f KeyProviderFunc) APIKey(ctx context.Context, tenant string) (string, error) {
+	return f(ctx, tenant)
+}
+
+// StaticKeys is a KeyProvider backed by a map of tenant to API key
+type StaticKeys map[string]string
+
+// APIKey implements KeyProvider
+func (k StaticKeys) APIKey(ctx context.Context, tenant string) (string, error) {
+	key, ok := k[tenant]
+	if !ok {
+		return "", fmt.Errorf("no API key for tenant %q", tenant)
+	}
+	return key, nil
+}
+
+// RateLimit is a token bucket: Rate requests per second on average with
+// bursts of up to Burst requests. A zero Rate is unlimited.
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+// ClientPoolOptions configures a ClientPool
+type ClientPoolOptions struct {
+	// Transport is shared by every tenant so connections are pooled,
+	// defaults to http.DefaultTransport
+	Transport http.RoundTripper
+	// RateLimit applies to each tenant separately, unlimited by default
+	RateLimit RateLimit
+	// TenantLimits overrides RateLimit for specific tenants
+	TenantLimits map[string]RateLimit
+	// ClientOptions are applied to every tenant's client, e.g. WithBaseURL or
+	// WithLogger. Options that replace the HTTP client, such as WithHTTPClient,
+	// would bypass the shared transport and the rate limit, so Client rejects
+	// them; wrap Transport instead.
+	ClientOptions []ClientOption
+	// Concurrency limits parallel requests of Health and Stats, defaults to 4
+	Concurrency int
+}
+
+// ClientPool holds one Client per tenant, created on first use from a KeyProvider.
+//
+// The API methods take no context, so the ctx passed to Client, Health and
+// Stats only bounds the key lookup; a request waiting for its tenant's rate
+// limit is not cancelled by it.
+type ClientPool struct {
+	keys KeyProvider
+	opts ClientPoolOptions
+
+	mu      sync.Mutex
+	clients map[string]*Client
+	// buckets outlive Evict so re-creating a client does not reset its limit
+	buckets map[string]*tokenBucket
+}
+
+// NewClientPool creates an empty pool
+func NewClientPool(keys KeyProvider, opts ClientPoolOptions) *ClientPool {
+	if opts.Transport == nil {
+		opts.Transport = http.DefaultTransport
+	}
+	return &ClientPool{keys: keys, opts: opts, clients: make(map[string]*Client), buckets: make(map[string]*tokenBucket)}
+}
+
+// Client returns the tenant's client, resolving its key and creating the
+// client the first time the tenant is seen
+func (p *ClientPool) Client(ctx context.Context, tenant string) (*Client, error) {
+	p.mu.Lock()
+	client, ok := p.clients[tenant]
+	p.mu.Unlock()
+	if ok {
+		return client, nil
+	}
+
+	key, err := p.keys.APIKey(ctx, tenant)
+	if err != nil {
+		return nil, fmt.Errorf("resolve API key of tenant %q: %w", tenant, err)
+	}
+	if key == "" {
+		// NewClient would fall back to UNSENT_API_KEY and send as the wrong tenant
+		return nil, fmt.Errorf("empty API key for tenant %q", tenant)
+	}
+	transport := p.opts.Transport
+	if bucket := p.bucket(tenant); bucket != nil {
+		transport = &rateLimitTransport{base: transport, bucket: bucket}
+	}
+	httpClient := &http.Client{Transport: transport}
+	options := append([]ClientOption{WithHTTPClient(httpClient)}, p.opts.ClientOptions...)
+	client, err = NewClient(key, options...)
+	if err != nil {
+		return nil, err
+	}
+	if client.HTTPClient != httpClient {
+		return nil, fmt.Errorf("client options of tenant %q replace the pool's HTTP client; set ClientPoolOptions.Transport instead", tenant)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	// another goroutine may have created the client meanwhile
+	if existing, ok := p.clients[tenant]; ok {
+		return existing, nil
+	}
+	p.clients[tenant] = client
+	return client, nil
+}
+
+// bucket returns the tenant's token bucket, creating it on first use, or nil
+// when the tenant is not rate limited
+func (p *ClientPool) bucket(tenant string) *tokenBucket {
+	limit, ok := p.opts.TenantLimits[tenant]
+	if !ok {
+		limit = p.opts.RateLimit
+	}
+	if limit.Rate <= 0 {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	bucket, ok := p.buckets[tenant]
+	if !ok {
+		bucket = newTokenBucket(limit)
+		p.buckets[tenant] = bucket
+	}
+	return bucket
+}
+
+// Evict drops the tenant's client so the next Client call resolves its key
+// again, e.g. after the key was rotated. The tenant's rate limit carries over.
+func (p *ClientPool) Evict(tenant string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.clients, tenant)
+}
+
+// Tenants lists the tenants with a client, sorted
+func (p *ClientPool) Tenants() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	tenants := make([]string, 0, len(p.clients))
+	for tenant := range p.clients {
+		tenants = append(tenants, tenant)
+	}
+	sort.Strings(tenants)
+	return tenants
+}
+
+// TenantHealth is the result of checking one tenant
+type TenantHealth struct {
+	Tenant  string
+	Healthy bool
+	Status  string
+	Team    *Team
+	Err     error
+}
+
+// PoolHealth aggregates the health of several tenants
+type PoolHealth struct {
+	Tenants   []TenantHealth
+	Healthy   int
+	Unhealthy int
+}
+
+// Health checks System.Health and Teams.Get for every tenant, or the
+// tenants with a client when none are given
+func (p *ClientPool) Health(ctx context.Context, tenants ...string) PoolHealth {
+	if len(tenants) == 0 {
+		tenants = p.Tenants()
+	}
+	results := make([]TenantHealth, len(tenants))
+	forEachConcurrent(len(tenants), p.opts.Concurrency, func(i int) {
+		result := TenantHealth{Tenant: tenants[i]}
+		defer func() { results[i] = result }()
+		client, err := p.Client(ctx, tenants[i])
+		if err != nil {
+			result.Err = err
+			return
+		}
+		health, apiErr := client.System.Health()
+		if apiErr != nil {
+			result.Err = apiErr
+			return
+		}
+		result.Status = health.Status
+		team, apiErr := client.Teams.Get()
+		if apiErr != nil {
+			result.Err = apiErr
+			return
+		}
+		result.Team = team
+		result.Healthy = true
+	})
+
+	report := PoolHealth{Tenants: results}
+	for _, result := range results {
+		if result.Healthy {
+			report.Healthy++
+		} else {
+			report.Unhealthy++
+		}
+	}
+	return report
+}
+
+// TenantStats are one tenant's email counts
+type TenantStats struct {
+	Tenant string
+	Stats  Stats
+	Err    error
+}
+
+// PoolStats aggregates email counts of several tenants. Total sums the
+// tenants without an error.
+type PoolStats struct {
+	Tenants []TenantStats
+	Total   Stats
+	Failed  int
+}
+
+// Stats reads Stats.Get with params for every tenant, or the tenants with a
+// client when none are given, and sums the counts
+func (p *ClientPool) Stats(ctx context.Context, params GetStatsParams, tenants ...string) PoolStats {
+	if len(tenants) == 0 {
+		tenants = p.Tenants()
+	}
+	results := make([]TenantStats, len(tenants))
+	forEachConcurrent(len(tenants), p.opts.Concurrency, func(i int) {
+		results[i].Tenant = tenants[i]
+		client, err := p.Client(ctx, tenants[i])
+		if err != nil {
+			results[i].Err = err
+			return
+		}
+		stats, apiErr := client.Stats.Get(params)
+		if apiErr != nil {
+			results[i].Err = apiErr
+			return
+		}
+		results[i].Stats = stats.Data
+	})
+
+	report := PoolStats{Tenants: results}
+	for _, result := range results {
+		if result.Err != nil {
+			report.Failed++
+			continue
+		}
+		s, t := result.Stats, &report.Total
+		t.Total += s.Total
+		t.Sent += s.Sent
+		t.Delivered += s.Delivered
+		t.Opened += s.Opened
+		t.Clicked += s.Clicked
+		t.Bounced += s.Bounced
+		t.Complained += s.Complained
+		t.Failed += s.Failed
+	}
+	return report
+}
+
+// rateLimitTransport delays requests until the tenant's bucket has a token
+type rateLimitTransport struct {
+	base   http.RoundTripper
+	bucket *tokenBucket
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.bucket.wait(req.Context()); err != nil {
+		return nil, err
+	}
+	return t.base.RoundTrip(req)
+}
+
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+	now    func() time.Time
+}
+
+func newTokenBucket(limit RateLimit) *tokenBucket {
+	burst := float64(limit.Burst)
+	if burst < 1 {
+		burst = 1
+	}
+	return &tokenBucket{rate: limit.Rate, burst: burst, tokens: burst, now: time.Now}
+}
+
+// wait takes a token, sleeping until one is available. A token is reserved
+// before sleeping so concurrent callers queue up in order.
+func (b *tokenBucket) wait(ctx context.Context) error {
+	b.mu.Lock()
+	now := b.now()
+	if !b.last.IsZero() {
+		b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	}
+	b.last = now
+	b.tokens--
+	var delay time.Duration
+	if b.tokens < 0 {
+		delay = time.Duration(-b.tokens / b.rate * float64(time.Second))
+	}
+	b.mu.Unlock()
+
+	if delay == 0 {
+		return nil
+	}
+	if err := sleepContext(ctx, delay); err != nil {
+		// give the reserved token back
+		b.mu.Lock()
+		b.tokens++
+		b.mu.Unlock()
+		return err
+	}
+	return nil
+}
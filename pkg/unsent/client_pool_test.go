@@ -0,0 +1,185 @@
+package unsent
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type countingTransport struct {
+	requests atomic.Int32
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.requests.Add(1)
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func newPoolServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if key == "revoked" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error": {"code": "UNAUTHORIZED", "message": "invalid key"}}`))
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/health":
+			w.Write([]byte(`{"status": "ok", "uptime": 12}`))
+		case "/v1/team":
+			w.Write([]byte(`{"id": "team_` + key + `", "name": "` + key + `"}`))
+		case "/v1/stats":
+			w.Write([]byte(`{"data": {"sent": 10, "delivered": 9, "bounced": 1}}`))
+		default:
+			t.Errorf("unexpected request %s", r.URL.Path)
+		}
+	}))
+}
+
+func TestClientPool_Client(t *testing.T) {
+	server := newPoolServer(t)
+	defer server.Close()
+
+	var lookups atomic.Int32
+	keys := KeyProviderFunc(func(ctx context.Context, tenant string) (string, error) {
+		lookups.Add(1)
+		return StaticKeys{"acme": "key_acme", "globex": "key_globex"}.APIKey(ctx, tenant)
+	})
+	transport := &countingTransport{}
+	pool := NewClientPool(keys, ClientPoolOptions{
+		Transport:     transport,
+		ClientOptions: []ClientOption{WithBaseURL(server.URL)},
+	})
+
+	acme, err := pool.Client(context.Background(), "acme")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if again, _ := pool.Client(context.Background(), "acme"); again != acme || lookups.Load() != 1 {
+		t.Errorf("expected the client to be reused, got %d key lookups", lookups.Load())
+	}
+	if acme.Key != "key_acme" {
+		t.Errorf("expected key_acme, got %s", acme.Key)
+	}
+	globex, _ := pool.Client(context.Background(), "globex")
+	team, apiErr := globex.Teams.Get()
+	if apiErr != nil || team.Name != "key_globex" {
+		t.Errorf("expected the globex key to be sent, got %+v, %v", team, apiErr)
+	}
+	acme.System.Health()
+	if transport.requests.Load() != 2 {
+		t.Errorf("expected both tenants to share the transport, got %d requests", transport.requests.Load())
+	}
+
+	if _, err := pool.Client(context.Background(), "initech"); err == nil || !strings.Contains(err.Error(), "initech") {
+		t.Errorf("expected a key lookup error, got %v", err)
+	}
+	if tenants := pool.Tenants(); len(tenants) != 2 || tenants[0] != "acme" {
+		t.Errorf("expected [acme globex], got %v", tenants)
+	}
+	pool.Evict("acme")
+	pool.Client(context.Background(), "acme")
+	if lookups.Load() != 4 {
+		t.Errorf("expected the evicted tenant's key to be resolved again, got %d lookups", lookups.Load())
+	}
+}
+
+func TestClientPool_RateLimit(t *testing.T) {
+	server := newPoolServer(t)
+	defer server.Close()
+
+	pool := NewClientPool(StaticKeys{"acme": "key_acme", "globex": "key_globex"}, ClientPoolOptions{
+		RateLimit:     RateLimit{Rate: 1000},
+		TenantLimits:  map[string]RateLimit{"acme": {Rate: 20, Burst: 2}},
+		ClientOptions: []ClientOption{WithBaseURL(server.URL)},
+	})
+	acme, _ := pool.Client(context.Background(), "acme")
+	globex, _ := pool.Client(context.Background(), "globex")
+
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		globex.System.Health()
+	}
+	if elapsed := time.Since(start); elapsed > 80*time.Millisecond {
+		t.Errorf("expected globex to be limited separately, took %v", elapsed)
+	}
+
+	start = time.Now()
+	for i := 0; i < 4; i++ {
+		if _, apiErr := acme.System.Health(); apiErr != nil {
+			t.Fatalf("unexpected error: %v", apiErr)
+		}
+	}
+	// a burst of 2, then 2 more at 20 per second
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("expected acme to be rate limited, took %v", elapsed)
+	}
+}
+
+func TestClientPool_HealthAndStats(t *testing.T) {
+	server := newPoolServer(t)
+	defer server.Close()
+
+	pool := NewClientPool(StaticKeys{"acme": "key_acme", "globex": "key_globex", "umbrella": "revoked"}, ClientPoolOptions{
+		ClientOptions: []ClientOption{WithBaseURL(server.URL)},
+	})
+	ctx := context.Background()
+
+	health := pool.Health(ctx, "acme", "globex", "umbrella", "initech")
+	if health.Healthy != 2 || health.Unhealthy != 2 {
+		t.Errorf("expected 2 healthy and 2 unhealthy tenants, got %+v", health)
+	}
+	if h := health.Tenants[0]; !h.Healthy || h.Status != "ok" || h.Team.ID != "team_key_acme" {
+		t.Errorf("unexpected acme health: %+v", h)
+	}
+	if apiErr, ok := health.Tenants[2].Err.(*APIError); !ok || apiErr.Code != "UNAUTHORIZED" {
+		t.Errorf("expected an unauthorized error for umbrella, got %v", health.Tenants[2].Err)
+	}
+
+	// defaults to the tenants with a client: acme, globex and umbrella
+	stats := pool.Stats(ctx, GetStatsParams{})
+	if len(stats.Tenants) != 3 || stats.Failed != 1 {
+		t.Errorf("expected one of 3 tenants to fail, got %+v", stats)
+	}
+	if stats.Total.Sent != 20 || stats.Total.Bounced != 2 {
+		t.Errorf("expected summed counts, got %+v", stats.Total)
+	}
+}
+
+func TestClientPool_RejectsHTTPClientOption(t *testing.T) {
+	pool := NewClientPool(StaticKeys{"acme": "key_acme"}, ClientPoolOptions{
+		RateLimit:     RateLimit{Rate: 1},
+		ClientOptions: []ClientOption{WithHTTPClient(&http.Client{})},
+	})
+	if _, err := pool.Client(context.Background(), "acme"); err == nil || !strings.Contains(err.Error(), "Transport") {
+		t.Errorf("expected the HTTP client option to be rejected, got %v", err)
+	}
+}
+
+func TestClientPool_EvictKeepsRateLimit(t *testing.T) {
+	server := newPoolServer(t)
+	defer server.Close()
+
+	pool := NewClientPool(StaticKeys{"acme": "key_acme"}, ClientPoolOptions{
+		RateLimit:     RateLimit{Rate: 20, Burst: 2},
+		ClientOptions: []ClientOption{WithBaseURL(server.URL)},
+	})
+	acme, _ := pool.Client(context.Background(), "acme")
+	acme.System.Health()
+	acme.System.Health()
+
+	pool.Evict("acme")
+	acme, _ = pool.Client(context.Background(), "acme")
+	start := time.Now()
+	acme.System.Health()
+	acme.System.Health()
+	// the burst was used up before evicting, so at least the second request
+	// waits a full token (50ms); a fresh limiter would not wait at all
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Errorf("expected the rate limit to carry over, took %v", elapsed)
+	}
+}
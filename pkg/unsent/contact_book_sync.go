@@ -0,0 +1,374 @@
+package unsent
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+
+	openapi_types "github.com/oapi-codegen/runtime/types"
+)
+
+// Record types written by ContactBooksClient.Export
+const (
+	ContactBookRecordBook    = "contactBook"
+	ContactBookRecordContact = "contact"
+)
+
+// ContactBookRecord is a single line of a contact book export. The first
+// record of an export describes the book, every following record is a contact.
+type ContactBookRecord struct {
+	Type        string       `json:"type"`
+	ContactBook *ContactBook `json:"contactBook,omitempty"`
+	Contact     *Contact     `json:"contact,omitempty"`
+}
+
+// Export writes a contact book, its property schema and all of its contacts
+// to w as JSONL and returns the number of contacts written
+func (c *ContactBooksClient) Export(bookID string, w io.Writer) (int, error) {
+	book, apiErr := c.Get(bookID)
+	if apiErr != nil {
+		return 0, apiErr
+	}
+	contacts, apiErr := c.client.Contacts.ListAll(bookID, 0)
+	if apiErr != nil {
+		return 0, apiErr
+	}
+
+	buf := bufio.NewWriter(w)
+	encoder := json.NewEncoder(buf)
+	if err := encoder.Encode(ContactBookRecord{Type: ContactBookRecordBook, ContactBook: book}); err != nil {
+		return 0, err
+	}
+	for i := range contacts {
+		if err := encoder.Encode(ContactBookRecord{Type: ContactBookRecordContact, Contact: &contacts[i]}); err != nil {
+			return i, err
+		}
+	}
+	return len(contacts), buf.Flush()
+}
+
+// ContactBookImportOptions configures ContactBooksClient.Import
+type ContactBookImportOptions struct {
+	// Name overrides the name of the recreated book
+	Name string
+	// Concurrency limits the number of in-flight contact creations, defaults to 4
+	Concurrency int
+}
+
+// ContactBookImportResult describes a recreated contact book
+type ContactBookImportResult struct {
+	BookID   string
+	Created  int
+	Failures []ContactSyncFailure
+}
+
+// Import recreates a contact book from an export produced by Export
+func (c *ContactBooksClient) Import(r io.Reader, opts ContactBookImportOptions) (*ContactBookImportResult, error) {
+	book, contacts, err := ReadContactBookExport(r)
+	if err != nil {
+		return nil, err
+	}
+
+	payload := CreateContactBookJSONBody{Name: book.Name}
+	if opts.Name != "" {
+		payload.Name = opts.Name
+	}
+	if book.Emoji != "" {
+		payload.Emoji = &book.Emoji
+	}
+	if len(book.Properties) > 0 {
+		payload.Properties = &book.Properties
+	}
+	created, apiErr := c.Create(payload)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	result := &ContactBookImportResult{BookID: created.ID}
+	failures := make([]*ContactSyncFailure, len(contacts))
+	forEachConcurrent(len(contacts), opts.Concurrency, func(i int) {
+		if _, err := c.client.Contacts.Create(created.ID, contactCreateBody(contacts[i])); err != nil {
+			failures[i] = &ContactSyncFailure{Email: contacts[i].Email, Op: ContactSyncAdd, Err: err}
+		}
+	})
+	for _, failure := range failures {
+		if failure != nil {
+			result.Failures = append(result.Failures, *failure)
+		}
+	}
+	result.Created = len(contacts) - len(result.Failures)
+	return result, nil
+}
+
+// ReadContactBookExport parses an export produced by Export
+func ReadContactBookExport(r io.Reader) (*ContactBook, []Contact, error) {
+	decoder := json.NewDecoder(r)
+	var book *ContactBook
+	var contacts []Contact
+	for line := 1; ; line++ {
+		var record ContactBookRecord
+		if err := decoder.Decode(&record); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, nil, fmt.Errorf("record %d: %w", line, err)
+		}
+		switch {
+		case record.Type == ContactBookRecordBook && record.ContactBook != nil && book == nil:
+			book = record.ContactBook
+		case record.Type == ContactBookRecordContact && record.Contact != nil && book != nil:
+			contacts = append(contacts, *record.Contact)
+		default:
+			return nil, nil, fmt.Errorf("record %d: unexpected %q record", line, record.Type)
+		}
+	}
+	if book == nil {
+		return nil, nil, fmt.Errorf("export contains no contact book record")
+	}
+	return book, contacts, nil
+}
+
+// ContactChange describes a contact present in both books with differing fields
+type ContactChange struct {
+	Email  string
+	Source Contact
+	Target Contact
+	Fields []string
+}
+
+// ContactBookDiff is the set of changes that make a target book match a source book
+type ContactBookDiff struct {
+	Adds     []Contact
+	Updates  []ContactChange
+	Removals []Contact
+}
+
+// Empty reports whether the books are already in sync
+func (d *ContactBookDiff) Empty() bool {
+	return len(d.Adds) == 0 && len(d.Updates) == 0 && len(d.Removals) == 0
+}
+
+// DiffContacts compares two sets of contacts by email (case-insensitively)
+// and returns the adds, updates and removals needed to turn target into source
+func DiffContacts(source, target []Contact) *ContactBookDiff {
+	targets := make(map[string]Contact, len(target))
+	for _, contact := range target {
+		targets[strings.ToLower(contact.Email)] = contact
+	}
+
+	diff := &ContactBookDiff{}
+	seen := make(map[string]bool, len(source))
+	for _, src := range source {
+		key := strings.ToLower(src.Email)
+		seen[key] = true
+		dst, ok := targets[key]
+		if !ok {
+			diff.Adds = append(diff.Adds, src)
+			continue
+		}
+		if fields := contactFieldChanges(src, dst); len(fields) > 0 {
+			diff.Updates = append(diff.Updates, ContactChange{Email: src.Email, Source: src, Target: dst, Fields: fields})
+		}
+	}
+	for _, dst := range target {
+		if !seen[strings.ToLower(dst.Email)] {
+			diff.Removals = append(diff.Removals, dst)
+		}
+	}
+	return diff
+}
+
+// contactFieldChanges lists the fields that differ between two contacts.
+// Names and the subscription are compared only when the source sets them,
+// while properties are compared both ways so removed ones are synced too.
+func contactFieldChanges(src, dst Contact) []string {
+	var fields []string
+	if src.FirstName != "" && src.FirstName != dst.FirstName {
+		fields = append(fields, "firstName")
+	}
+	if src.LastName != "" && src.LastName != dst.LastName {
+		fields = append(fields, "lastName")
+	}
+	if src.Subscribed != nil && (dst.Subscribed == nil || *src.Subscribed != *dst.Subscribed) {
+		fields = append(fields, "subscribed")
+	}
+	srcProps, dstProps := contactPropertyStrings(src), contactPropertyStrings(dst)
+	if (len(srcProps) > 0 || len(dstProps) > 0) && !reflect.DeepEqual(srcProps, dstProps) {
+		fields = append(fields, "properties")
+	}
+	return fields
+}
+
+// ContactSyncOp identifies the operation a sync failure occurred in
+type ContactSyncOp string
+
+const (
+	ContactSyncAdd    ContactSyncOp = "add"
+	ContactSyncUpdate ContactSyncOp = "update"
+	ContactSyncRemove ContactSyncOp = "remove"
+)
+
+// ContactSyncFailure records a contact that could not be written
+type ContactSyncFailure struct {
+	Email string
+	Op    ContactSyncOp
+	Err   *APIError
+}
+
+// ContactBookSyncOptions configures SyncContactBooks
+type ContactBookSyncOptions struct {
+	// DryRun computes the diff without writing to the target book
+	DryRun bool
+	// Remove deletes target contacts that are missing from the source
+	Remove bool
+	// Concurrency limits the number of in-flight writes, defaults to 4
+	Concurrency int
+}
+
+// ContactBookSyncResult reports the diff and the writes applied to the target
+type ContactBookSyncResult struct {
+	Diff     *ContactBookDiff
+	Added    int
+	Updated  int
+	Removed  int
+	Failures []ContactSyncFailure
+}
+
+// SyncContactBooks makes the target book match the source book. The books may
+// live on different teams, so each side takes its own client.
+func SyncContactBooks(source *Client, sourceBookID string, target *Client, targetBookID string, opts ContactBookSyncOptions) (*ContactBookSyncResult, error) {
+	srcContacts, apiErr := source.Contacts.ListAll(sourceBookID, 0)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	dstContacts, apiErr := target.Contacts.ListAll(targetBookID, 0)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+
+	diff := DiffContacts(srcContacts, dstContacts)
+	result := &ContactBookSyncResult{Diff: diff}
+	if opts.DryRun || diff.Empty() {
+		return result, nil
+	}
+
+	type write struct {
+		op    ContactSyncOp
+		email string
+		do    func() *APIError
+	}
+	var writes []write
+	for _, contact := range diff.Adds {
+		body := contactCreateBody(contact)
+		writes = append(writes, write{ContactSyncAdd, contact.Email, func() *APIError {
+			_, err := target.Contacts.Create(targetBookID, body)
+			return err
+		}})
+	}
+	for _, change := range diff.Updates {
+		id, body := change.Target.ID, contactUpdateBody(change)
+		writes = append(writes, write{ContactSyncUpdate, change.Email, func() *APIError {
+			_, err := target.Contacts.Update(targetBookID, id, body)
+			return err
+		}})
+	}
+	if opts.Remove {
+		for _, contact := range diff.Removals {
+			id := contact.ID
+			writes = append(writes, write{ContactSyncRemove, contact.Email, func() *APIError {
+				_, err := target.Contacts.Delete(targetBookID, id)
+				return err
+			}})
+		}
+	}
+
+	errs := make([]*APIError, len(writes))
+	forEachConcurrent(len(writes), opts.Concurrency, func(i int) {
+		errs[i] = writes[i].do()
+	})
+	for i, err := range errs {
+		if err != nil {
+			result.Failures = append(result.Failures, ContactSyncFailure{Email: writes[i].email, Op: writes[i].op, Err: err})
+			continue
+		}
+		switch writes[i].op {
+		case ContactSyncAdd:
+			result.Added++
+		case ContactSyncUpdate:
+			result.Updated++
+		case ContactSyncRemove:
+			result.Removed++
+		}
+	}
+	return result, nil
+}
+
+// contactPropertyStrings flattens contact metadata into the string form used by create and update bodies
+func contactPropertyStrings(contact Contact) map[string]string {
+	if len(contact.Metadata) == 0 {
+		return nil
+	}
+	props := make(map[string]string, len(contact.Metadata))
+	for key, value := range contact.Metadata {
+		if value == nil {
+			continue
+		}
+		props[key] = contactPropertyString(value)
+	}
+	return props
+}
+
+// contactPropertyString formats a metadata value. JSON numbers decode to
+// float64, which fmt would print as 1e+06.
+func contactPropertyString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	return fmt.Sprint(value)
+}
+
+func contactCreateBody(contact Contact) CreateContactJSONBody {
+	body := CreateContactJSONBody{Email: openapi_types.Email(contact.Email), Subscribed: contact.Subscribed}
+	if contact.FirstName != "" {
+		body.FirstName = &contact.FirstName
+	}
+	if contact.LastName != "" {
+		body.LastName = &contact.LastName
+	}
+	if props := contactPropertyStrings(contact); len(props) > 0 {
+		body.Properties = &props
+	}
+	return body
+}
+
+// contactUpdateBody sends only the fields of a change. Changed properties
+// are sent whole, as an empty map when the source has none left.
+func contactUpdateBody(change ContactChange) UpdateContactJSONBody {
+	var body UpdateContactJSONBody
+	src := change.Source
+	for _, field := range change.Fields {
+		switch field {
+		case "firstName":
+			body.FirstName = &src.FirstName
+		case "lastName":
+			body.LastName = &src.LastName
+		case "subscribed":
+			body.Subscribed = src.Subscribed
+		case "properties":
+			props := contactPropertyStrings(src)
+			if props == nil {
+				props = map[string]string{}
+			}
+			body.Properties = &props
+		}
+	}
+	return body
+}
@@ -0,0 +1,199 @@
+package unsent
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func boolRef(b bool) *bool {
+	return &b
+}
+
+func TestContacts_ListAll(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page := r.URL.Query().Get("page")
+		switch {
+		// the server caps the page size at 2, below the 3 requested
+		case strings.HasPrefix(page, "1"):
+			w.Write([]byte(`[{"id": "c1", "email": "a@example.com"}, {"id": "c2", "email": "b@example.com"}]`))
+		case strings.HasPrefix(page, "2"):
+			w.Write([]byte(`[{"id": "c3", "email": "c@example.com"}, {"id": "c4", "email": "d@example.com"}]`))
+		case strings.HasPrefix(page, "3"):
+			w.Write([]byte(`[]`))
+		default:
+			t.Errorf("unexpected page %q", page)
+		}
+	}))
+	defer server.Close()
+
+	client, _ := NewClient("key", WithBaseURL(server.URL))
+	contacts, err := client.Contacts.ListAll("book1", 3)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(contacts) != 4 || contacts[3].ID != "c4" {
+		t.Errorf("unexpected contacts: %+v", contacts)
+	}
+}
+
+func TestContactBooks_ExportImport(t *testing.T) {
+	var createdBook CreateContactBookJSONBody
+	var createdContacts []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/v1/contactBooks/book1":
+			w.Write([]byte(`{"id": "book1", "name": "Newsletter", "emoji": "📬", "properties": {"plan": "string"}}`))
+		case r.Method == "GET" && r.URL.Path == "/v1/contactBooks/book1/contacts":
+			if strings.HasPrefix(r.URL.Query().Get("page"), "1") {
+				w.Write([]byte(`[{"id": "c1", "email": "a@example.com", "subscribed": true, "metadata": {"plan": "pro"}}]`))
+			} else {
+				w.Write([]byte(`[]`))
+			}
+		case r.Method == "POST" && r.URL.Path == "/v1/contactBooks":
+			json.NewDecoder(r.Body).Decode(&createdBook)
+			w.Write([]byte(`{"id": "book2"}`))
+		case r.Method == "POST" && r.URL.Path == "/v1/contactBooks/book2/contacts":
+			var body CreateContactJSONBody
+			json.NewDecoder(r.Body).Decode(&body)
+			createdContacts = append(createdContacts, fmt.Sprintf("%s:%s", body.Email, (*body.Properties)["plan"]))
+			w.Write([]byte(`{"contactId": "c9"}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	client, _ := NewClient("key", WithBaseURL(server.URL))
+
+	var buf bytes.Buffer
+	n, err := client.ContactBooks.Export("book1", &buf)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n != 1 || strings.Count(buf.String(), "\n") != 2 {
+		t.Fatalf("unexpected export (%d contacts):\n%s", n, buf.String())
+	}
+
+	result, err := client.ContactBooks.Import(&buf, ContactBookImportOptions{Name: "Newsletter (copy)"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.BookID != "book2" || result.Created != 1 {
+		t.Errorf("unexpected result: %+v", result)
+	}
+	if createdBook.Name != "Newsletter (copy)" || (*createdBook.Properties)["plan"] != "string" {
+		t.Errorf("unexpected book: %+v", createdBook)
+	}
+	if len(createdContacts) != 1 || createdContacts[0] != "a@example.com:pro" {
+		t.Errorf("unexpected contacts: %v", createdContacts)
+	}
+}
+
+func TestDiffContacts(t *testing.T) {
+	source := []Contact{
+		{Email: "a@example.com", FirstName: "Ada", Subscribed: boolRef(true)},
+		{Email: "b@example.com", Subscribed: boolRef(false)},
+		{Email: "c@example.com"},
+	}
+	target := []Contact{
+		{ID: "t1", Email: "A@example.com", FirstName: "Ada", Subscribed: boolRef(true)},
+		{ID: "t2", Email: "b@example.com", Subscribed: boolRef(true)},
+		{ID: "t3", Email: "d@example.com"},
+	}
+
+	diff := DiffContacts(source, target)
+	if len(diff.Adds) != 1 || diff.Adds[0].Email != "c@example.com" {
+		t.Errorf("unexpected adds: %+v", diff.Adds)
+	}
+	if len(diff.Updates) != 1 || diff.Updates[0].Fields[0] != "subscribed" {
+		t.Errorf("unexpected updates: %+v", diff.Updates)
+	}
+	if len(diff.Removals) != 1 || diff.Removals[0].ID != "t3" {
+		t.Errorf("unexpected removals: %+v", diff.Removals)
+	}
+}
+
+func TestDiffContacts_OnlyChangedFields(t *testing.T) {
+	source := []Contact{{Email: "a@example.com", LastName: "Lovelace"}}
+	target := []Contact{{ID: "t1", Email: "a@example.com", FirstName: "Ada", LastName: "King", Metadata: map[string]interface{}{"plan": "pro"}}}
+
+	diff := DiffContacts(source, target)
+	if len(diff.Updates) != 1 || strings.Join(diff.Updates[0].Fields, ",") != "lastName,properties" {
+		t.Fatalf("expected lastName and removed properties to differ, got %+v", diff.Updates)
+	}
+	body, _ := json.Marshal(contactUpdateBody(diff.Updates[0]))
+	if string(body) != `{"lastName":"Lovelace","properties":{}}` {
+		t.Errorf("expected only the changed fields, got %s", body)
+	}
+}
+
+func TestContactPropertyStrings(t *testing.T) {
+	var contact Contact
+	json.Unmarshal([]byte(`{"email": "a@example.com", "metadata": {"employees": 1500000, "score": 2.5, "active": true, "plan": "pro"}}`), &contact)
+	props := contactPropertyStrings(contact)
+	want := map[string]string{"employees": "1500000", "score": "2.5", "active": "true", "plan": "pro"}
+	for key, value := range want {
+		if props[key] != value {
+			t.Errorf("expected %s=%s, got %q", key, value, props[key])
+		}
+	}
+
+	target := Contact{ID: "t1", Email: "a@example.com", Metadata: map[string]interface{}{"employees": "1500000", "score": "2.5", "active": "true", "plan": "pro"}}
+	if diff := DiffContacts([]Contact{contact}, []Contact{target}); len(diff.Updates) != 0 {
+		t.Errorf("expected large numbers not to produce a diff, got %+v", diff.Updates)
+	}
+}
+
+func TestSyncContactBooks(t *testing.T) {
+	source := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Query().Get("page"), "1") {
+			w.Write([]byte(`[]`))
+			return
+		}
+		w.Write([]byte(`[{"id": "s1", "email": "a@example.com", "subscribed": false}, {"id": "s2", "email": "new@example.com"}]`))
+	}))
+	defer source.Close()
+
+	var writes []string
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" && !strings.HasPrefix(r.URL.Query().Get("page"), "1") {
+			w.Write([]byte(`[]`))
+			return
+		}
+		if r.Method == "GET" {
+			w.Write([]byte(`[{"id": "t1", "email": "a@example.com", "subscribed": true}, {"id": "t2", "email": "gone@example.com"}]`))
+			return
+		}
+		writes = append(writes, r.Method+" "+r.URL.Path)
+		w.Write([]byte(`{}`))
+	}))
+	defer target.Close()
+
+	src, _ := NewClient("key", WithBaseURL(source.URL))
+	dst, _ := NewClient("key", WithBaseURL(target.URL))
+
+	result, err := SyncContactBooks(src, "staging", dst, "prod", ContactBookSyncOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(writes) != 0 || len(result.Diff.Adds) != 1 || len(result.Diff.Updates) != 1 || len(result.Diff.Removals) != 1 {
+		t.Fatalf("unexpected dry run: writes=%v diff=%+v", writes, result.Diff)
+	}
+
+	result, err = SyncContactBooks(src, "staging", dst, "prod", ContactBookSyncOptions{Remove: true, Concurrency: 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Added != 1 || result.Updated != 1 || result.Removed != 1 || len(result.Failures) != 0 {
+		t.Errorf("unexpected result: %+v", result)
+	}
+	expected := []string{"POST /v1/contactBooks/prod/contacts", "PATCH /v1/contactBooks/prod/contacts/t1", "DELETE /v1/contactBooks/prod/contacts/t2"}
+	if strings.Join(writes, ",") != strings.Join(expected, ",") {
+		t.Errorf("expected writes %v, got %v", expected, writes)
+	}
+}
@@ -0,0 +1,426 @@
+package unsent
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	openapi_types "github.com/oapi-codegen/runtime/types"
+)
+
+// ContactImportFormat identifies the encoding of a contact import source
+type ContactImportFormat string
+
+const (
+	ContactImportCSV   ContactImportFormat = "csv"
+	ContactImportJSONL ContactImportFormat = "jsonl"
+)
+
+// ContactColumnMapping maps source columns to contact fields.
+// Properties maps a source column to a property declared on the contact book.
+type ContactColumnMapping struct {
+	Email      string
+	FirstName  string
+	LastName   string
+	Subscribed string
+	Properties map[string]string
+}
+
+// ContactImportOptions configures ContactsClient.Import
+type ContactImportOptions struct {
+	// Format of the source, defaults to CSV
+	Format ContactImportFormat
+	// Mapping of source columns to contact fields. When nil the mapping is
+	// inferred from the column names and the book's declared properties.
+	Mapping *ContactColumnMapping
+	// Concurrency limits the number of in-flight create/update calls, defaults to 4
+	Concurrency int
+	// BatchSize is the number of rows looked up per Contacts.List call, defaults to 100
+	BatchSize int
+	// IgnoreUnknownColumns drops columns that map to no field instead of failing
+	IgnoreUnknownColumns bool
+}
+
+// ContactImportStatus is the outcome of importing a single row
+type ContactImportStatus string
+
+const (
+	ContactImportCreated ContactImportStatus = "created"
+	ContactImportUpdated ContactImportStatus = "updated"
+	ContactImportSkipped ContactImportStatus = "skipped"
+	ContactImportFailed  ContactImportStatus = "failed"
+)
+
+// ContactImportRow is the result for a single source row
+type ContactImportRow struct {
+	Line      int
+	Email     string
+	Status    ContactImportStatus
+	ContactID string
+	Reason    string
+	Err       *APIError
+}
+
+// ContactImportSummary aggregates the results of an import
+type ContactImportSummary struct {
+	Created int
+	Updated int
+	Skipped int
+	Failed  int
+	Rows    []ContactImportRow
+}
+
+func (s *ContactImportSummary) add(row ContactImportRow) {
+	switch row.Status {
+	case ContactImportCreated:
+		s.Created++
+	case ContactImportUpdated:
+		s.Updated++
+	case ContactImportSkipped:
+		s.Skipped++
+	case ContactImportFailed:
+		s.Failed++
+	}
+	s.Rows = append(s.Rows, row)
+}
+
+// contactImportRecord is a parsed row waiting to be written
+type contactImportRecord struct {
+	line int
+	body CreateContactJSONBody
+}
+
+// Import streams contacts from a CSV or JSONL source into a contact book.
+// Rows are validated against the book's declared properties, deduplicated by
+// email and written with Contacts.Create or Contacts.Update depending on
+// whether the email already exists in the book. Per-row failures are
+// recorded in the summary; the returned error is reserved for failures that
+// stop the import (unreadable source, unknown columns, book lookup).
+func (c *ContactsClient) Import(bookID string, r io.Reader, opts ContactImportOptions) (*ContactImportSummary, error) {
+	book, apiErr := c.client.ContactBooks.Get(bookID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 4
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 100
+	}
+	if opts.Mapping != nil {
+		for column, property := range opts.Mapping.Properties {
+			if _, ok := book.Properties[property]; !ok {
+				return nil, fmt.Errorf("column %q maps to property %q which is not declared on contact book %s", column, property, bookID)
+			}
+		}
+	}
+
+	next, err := newContactImportSource(r, opts.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &ContactImportSummary{}
+	seen := make(map[string]int)
+	batch := make([]contactImportRecord, 0, opts.BatchSize)
+	flush := func() {
+		for _, row := range c.importBatch(bookID, batch, opts.Concurrency) {
+			summary.add(row)
+		}
+		batch = batch[:0]
+	}
+
+	for {
+		line, fields, err := next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			flush()
+			return summary, err
+		}
+
+		mapping := opts.Mapping
+		if mapping == nil {
+			mapping, err = inferContactMapping(fields, book.Properties, opts.IgnoreUnknownColumns)
+			if err != nil {
+				flush()
+				return summary, err
+			}
+		}
+
+		body, reason := buildContactImportBody(fields, mapping, book.Properties)
+		email := string(body.Email)
+		if reason != "" {
+			status := ContactImportFailed
+			if email == "" {
+				status = ContactImportSkipped
+			}
+			summary.add(ContactImportRow{Line: line, Email: email, Status: status, Reason: reason})
+			continue
+		}
+
+		key := strings.ToLower(email)
+		if first, ok := seen[key]; ok {
+			summary.add(ContactImportRow{Line: line, Email: email, Status: ContactImportSkipped, Reason: fmt.Sprintf("duplicate of line %d", first)})
+			continue
+		}
+		seen[key] = line
+
+		batch = append(batch, contactImportRecord{line: line, body: body})
+		if len(batch) == opts.BatchSize {
+			flush()
+		}
+	}
+	flush()
+
+	return summary, nil
+}
+
+// importBatch writes a batch of records, updating contacts whose email already exists
+func (c *ContactsClient) importBatch(bookID string, batch []contactImportRecord, concurrency int) []ContactImportRow {
+	if len(batch) == 0 {
+		return nil
+	}
+
+	rows := make([]ContactImportRow, len(batch))
+	emails := make([]string, len(batch))
+	for i, rec := range batch {
+		emails[i] = string(rec.body.Email)
+	}
+
+	// List puts the emails into the query as is, so escape them here, and ask
+	// for a page large enough to hold every match of the batch
+	escaped := make([]string, len(emails))
+	for i, email := range emails {
+		escaped[i] = url.QueryEscape(email)
+	}
+	joined := strings.Join(escaped, ",")
+	limit := float32(len(batch))
+	existing, apiErr := c.List(bookID, GetContactsParams{Emails: &joined, Limit: &limit})
+	if apiErr != nil {
+		for i, rec := range batch {
+			rows[i] = ContactImportRow{Line: rec.line, Email: emails[i], Status: ContactImportFailed, Reason: "lookup failed", Err: apiErr}
+		}
+		return rows
+	}
+	ids := make(map[string]string, len(*existing))
+	for _, contact := range *existing {
+		ids[strings.ToLower(contact.Email)] = contact.ID
+	}
+
+	forEachConcurrent(len(batch), concurrency, func(i int) {
+		rec := batch[i]
+		row := ContactImportRow{Line: rec.line, Email: emails[i]}
+		if id, ok := ids[strings.ToLower(row.Email)]; ok {
+			_, err := c.Update(bookID, id, UpdateContactJSONBody{
+				FirstName:  rec.body.FirstName,
+				LastName:   rec.body.LastName,
+				Properties: rec.body.Properties,
+				Subscribed: rec.body.Subscribed,
+			})
+			row.ContactID = id
+			row.Status = ContactImportUpdated
+			if err != nil {
+				row.Status = ContactImportFailed
+				row.Err = err
+			}
+		} else {
+			resp, err := c.Create(bookID, rec.body)
+			row.Status = ContactImportCreated
+			if err != nil {
+				row.Status = ContactImportFailed
+				row.Err = err
+			} else {
+				row.ContactID = resp.ID
+			}
+		}
+		rows[i] = row
+	})
+
+	return rows
+}
+
+// newContactImportSource returns a function yielding the line number and
+// column values of each source row
+func newContactImportSource(r io.Reader, format ContactImportFormat) (func() (int, map[string]string, error), error) {
+	switch format {
+	case "", ContactImportCSV:
+		reader := csv.NewReader(r)
+		reader.FieldsPerRecord = -1
+		reader.TrimLeadingSpace = true
+		header, err := reader.Read()
+		if err != nil {
+			return nil, fmt.Errorf("reading CSV header: %w", err)
+		}
+		for i := range header {
+			header[i] = strings.TrimSpace(strings.TrimPrefix(header[i], "\ufeff"))
+		}
+		return func() (int, map[string]string, error) {
+			record, err := reader.Read()
+			if err != nil {
+				return 0, nil, err
+			}
+			line, _ := reader.FieldPos(0)
+			fields := make(map[string]string, len(header))
+			for i, name := range header {
+				if i < len(record) {
+					fields[name] = strings.TrimSpace(record[i])
+				}
+			}
+			return line, fields, nil
+		}, nil
+	case ContactImportJSONL:
+		decoder := json.NewDecoder(r)
+		decoder.UseNumber()
+		line := 0
+		return func() (int, map[string]string, error) {
+			var object map[string]interface{}
+			if err := decoder.Decode(&object); err != nil {
+				return 0, nil, err
+			}
+			line++
+			fields := make(map[string]string, len(object))
+			for key, value := range object {
+				fields[key] = stringifyImportValue(value)
+			}
+			return line, fields, nil
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported contact import format %q", format)
+	}
+}
+
+func stringifyImportValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return strings.TrimSpace(v)
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		b, _ := json.Marshal(v)
+		return string(b)
+	}
+}
+
+// normalizeColumnName folds case and separators so that "First Name",
+// "first_name" and "firstName" compare equal
+func normalizeColumnName(name string) string {
+	return strings.NewReplacer("_", "", "-", "", " ", "").Replace(strings.ToLower(name))
+}
+
+// inferContactMapping builds a mapping from column names and the book's declared properties
+func inferContactMapping(fields map[string]string, properties map[string]string, ignoreUnknown bool) (*ContactColumnMapping, error) {
+	declared := make(map[string]string, len(properties))
+	for name := range properties {
+		declared[normalizeColumnName(name)] = name
+	}
+
+	mapping := &ContactColumnMapping{Properties: make(map[string]string)}
+	for column := range fields {
+		switch normalizeColumnName(column) {
+		case "email", "emailaddress":
+			mapping.Email = column
+		case "firstname":
+			mapping.FirstName = column
+		case "lastname":
+			mapping.LastName = column
+		case "subscribed":
+			mapping.Subscribed = column
+		default:
+			if property, ok := declared[normalizeColumnName(column)]; ok {
+				mapping.Properties[column] = property
+			} else if !ignoreUnknown {
+				return nil, fmt.Errorf("column %q does not match a contact field or a declared property", column)
+			}
+		}
+	}
+	if mapping.Email == "" {
+		return nil, errors.New("no email column found")
+	}
+	return mapping, nil
+}
+
+// buildContactImportBody converts a row into a create body, returning a
+// non-empty reason when the row cannot be imported
+func buildContactImportBody(fields map[string]string, mapping *ContactColumnMapping, properties map[string]string) (CreateContactJSONBody, string) {
+	body := CreateContactJSONBody{Email: openapi_types.Email(fields[mapping.Email])}
+	if body.Email == "" {
+		return body, "missing email"
+	}
+	if !strings.Contains(string(body.Email), "@") {
+		return body, fmt.Sprintf("invalid email %q", body.Email)
+	}
+	if v := fields[mapping.FirstName]; mapping.FirstName != "" && v != "" {
+		body.FirstName = &v
+	}
+	if v := fields[mapping.LastName]; mapping.LastName != "" && v != "" {
+		body.LastName = &v
+	}
+	if v := fields[mapping.Subscribed]; mapping.Subscribed != "" && v != "" {
+		subscribed, ok := parseBoolish(v)
+		if !ok {
+			return body, fmt.Sprintf("invalid subscribed value %q", v)
+		}
+		body.Subscribed = &subscribed
+	}
+
+	props := make(map[string]string)
+	for column, property := range mapping.Properties {
+		value, ok := fields[column]
+		if !ok || value == "" {
+			continue
+		}
+		if err := validatePropertyValue(properties[property], value); err != nil {
+			return body, fmt.Sprintf("property %q: %v", property, err)
+		}
+		props[property] = value
+	}
+	if len(props) > 0 {
+		body.Properties = &props
+	}
+	return body, ""
+}
+
+// validatePropertyValue checks a value against the type declared on the contact book.
+// Unknown or free-form types accept any value.
+func validatePropertyValue(kind, value string) error {
+	switch strings.ToLower(kind) {
+	case "number":
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return fmt.Errorf("%q is not a number", value)
+		}
+	case "boolean", "bool":
+		if _, ok := parseBoolish(value); !ok {
+			return fmt.Errorf("%q is not a boolean", value)
+		}
+	case "date", "datetime":
+		if _, err := time.Parse(time.RFC3339, value); err == nil {
+			return nil
+		}
+		if _, err := time.Parse(time.DateOnly, value); err != nil {
+			return fmt.Errorf("%q is not a date", value)
+		}
+	}
+	return nil
+}
+
+func parseBoolish(value string) (bool, bool) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "true", "t", "1", "yes", "y":
+		return true, true
+	case "false", "f", "0", "no", "n":
+		return false, true
+	}
+	return false, false
+}
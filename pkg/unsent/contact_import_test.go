@@ -0,0 +1,153 @@
+package unsent
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func newContactImportServer(t *testing.T, created, updated *[]CreateContactJSONBody) *httptest.Server {
+	var mu sync.Mutex
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/v1/contactBooks/book1":
+			w.Write([]byte(`{"id": "book1", "properties": {"plan": "string", "seats": "number"}}`))
+		case r.Method == "GET" && r.URL.Path == "/v1/contactBooks/book1/contacts":
+			if !strings.Contains(r.URL.Query().Get("emails"), "old@example.com") {
+				t.Errorf("expected lookup to include old@example.com, got %s", r.URL.RawQuery)
+			}
+			w.Write([]byte(`[{"id": "c_old", "email": "old@example.com"}]`))
+		case r.Method == "POST" && r.URL.Path == "/v1/contactBooks/book1/contacts":
+			var body CreateContactJSONBody
+			json.NewDecoder(r.Body).Decode(&body)
+			mu.Lock()
+			*created = append(*created, body)
+			mu.Unlock()
+			w.Write([]byte(`{"contactId": "c_new", "email": "` + string(body.Email) + `"}`))
+		case r.Method == "PATCH" && r.URL.Path == "/v1/contactBooks/book1/contacts/c_old":
+			var body CreateContactJSONBody
+			json.NewDecoder(r.Body).Decode(&body)
+			mu.Lock()
+			*updated = append(*updated, body)
+			mu.Unlock()
+			w.Write([]byte(`{"id": "c_old"}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestContacts_ImportCSV(t *testing.T) {
+	var created, updated []CreateContactJSONBody
+	server := newContactImportServer(t, &created, &updated)
+	defer server.Close()
+
+	client, _ := NewClient("key", WithBaseURL(server.URL))
+
+	csv := "Email,First Name,subscribed,seats,plan\n" +
+		"new@example.com,Ada,yes,3,pro\n" +
+		"old@example.com,Bob,no,1,free\n" +
+		"NEW@example.com,Dup,yes,2,pro\n" +
+		",Nobody,yes,1,free\n" +
+		"bad@example.com,Eve,yes,many,pro\n"
+
+	summary, err := client.Contacts.Import("book1", strings.NewReader(csv), ContactImportOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if summary.Created != 1 || summary.Updated != 1 || summary.Skipped != 2 || summary.Failed != 1 {
+		t.Errorf("unexpected summary: %+v", summary)
+	}
+	if len(created) != 1 || *created[0].FirstName != "Ada" || !*created[0].Subscribed {
+		t.Fatalf("unexpected created contacts: %+v", created)
+	}
+	if (*created[0].Properties)["seats"] != "3" {
+		t.Errorf("expected seats 3, got %v", *created[0].Properties)
+	}
+	if len(updated) != 1 || *updated[0].Subscribed {
+		t.Errorf("unexpected updated contacts: %+v", updated)
+	}
+}
+
+func TestContacts_ImportJSONLWithMapping(t *testing.T) {
+	var created, updated []CreateContactJSONBody
+	server := newContactImportServer(t, &created, &updated)
+	defer server.Close()
+
+	client, _ := NewClient("key", WithBaseURL(server.URL))
+
+	jsonl := `{"mail": "old@example.com", "tier": "pro", "ignored": true}` + "\n" +
+		`{"mail": "new@example.com", "tier": "free"}` + "\n"
+
+	summary, err := client.Contacts.Import("book1", strings.NewReader(jsonl), ContactImportOptions{
+		Format:  ContactImportJSONL,
+		Mapping: &ContactColumnMapping{Email: "mail", Properties: map[string]string{"tier": "plan"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if summary.Created != 1 || summary.Updated != 1 {
+		t.Errorf("unexpected summary: %+v", summary)
+	}
+	if (*created[0].Properties)["plan"] != "free" {
+		t.Errorf("expected plan free, got %v", *created[0].Properties)
+	}
+}
+
+func TestContacts_ImportRejectsUndeclaredProperty(t *testing.T) {
+	var created, updated []CreateContactJSONBody
+	server := newContactImportServer(t, &created, &updated)
+	defer server.Close()
+
+	client, _ := NewClient("key", WithBaseURL(server.URL))
+
+	_, err := client.Contacts.Import("book1", strings.NewReader("email,company\na@example.com,Acme\n"), ContactImportOptions{})
+	if err == nil {
+		t.Fatal("expected error for undeclared column")
+	}
+	_, err = client.Contacts.Import("book1", strings.NewReader("email\n"), ContactImportOptions{
+		Mapping: &ContactColumnMapping{Email: "email", Properties: map[string]string{"x": "company"}},
+	})
+	if err == nil {
+		t.Fatal("expected error for undeclared property mapping")
+	}
+}
+
+func TestContacts_ImportLookupEscapesEmails(t *testing.T) {
+	var created int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/v1/contactBooks/book1":
+			w.Write([]byte(`{"id": "book1"}`))
+		case r.Method == "GET" && r.URL.Path == "/v1/contactBooks/book1/contacts":
+			if emails := r.URL.Query().Get("emails"); emails != "a+tag@example.com,b@example.com" {
+				t.Errorf("expected escaped emails, got %q", emails)
+			}
+			if r.URL.Query().Get("limit") == "" {
+				t.Errorf("expected a limit, got %s", r.URL.RawQuery)
+			}
+			w.Write([]byte(`[{"id": "c_tag", "email": "a+tag@example.com"}]`))
+		case r.Method == "PATCH" && r.URL.Path == "/v1/contactBooks/book1/contacts/c_tag":
+			w.Write([]byte(`{"id": "c_tag"}`))
+		case r.Method == "POST" && r.URL.Path == "/v1/contactBooks/book1/contacts":
+			created++
+			w.Write([]byte(`{"contactId": "c_new"}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+	client, _ := NewClient("key", WithBaseURL(server.URL))
+
+	summary, err := client.Contacts.Import("book1", strings.NewReader("email\na+tag@example.com\nb@example.com\n"), ContactImportOptions{Concurrency: 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if summary.Updated != 1 || summary.Created != 1 || created != 1 {
+		t.Errorf("expected a+tag to be updated and b to be created, got %+v", summary)
+	}
+}
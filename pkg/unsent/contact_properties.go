@@ -0,0 +1,339 @@
+package unsent
+
+import (
+	"encoding"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// TypedContact is a contact whose properties are decoded into T
+type TypedContact[T any] struct {
+	Contact
+	Properties T
+}
+
+// ContactsOf retrieves every contact in a contact book and decodes their
+// properties into T. Fields of T are mapped to properties with the `unsent`
+// struct tag (`unsent:"plan"`, `unsent:"-"` to skip); untagged fields use
+// their name with a lower-case first letter. Every mapped field must be
+// declared on the contact book.
+func ContactsOf[T any](client *Client, bookID string) ([]TypedContact[T], error) {
+	book, apiErr := client.ContactBooks.Get(bookID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	var zero T
+	if err := checkDeclaredProperties(reflect.TypeOf(zero), book.Properties); err != nil {
+		return nil, err
+	}
+
+	contacts, apiErr := client.Contacts.ListAll(bookID, 0)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	typed := make([]TypedContact[T], len(contacts))
+	for i, contact := range contacts {
+		typed[i].Contact = contact
+		if err := DecodeContactProperties(contact.Metadata, &typed[i].Properties); err != nil {
+			return nil, fmt.Errorf("contact %s: %w", contact.Email, err)
+		}
+	}
+	return typed, nil
+}
+
+// GetTypedContact retrieves a single contact and decodes its properties into T
+func GetTypedContact[T any](client *Client, bookID, contactID string) (*TypedContact[T], error) {
+	contact, apiErr := client.Contacts.Get(bookID, contactID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	typed := &TypedContact[T]{Contact: *contact}
+	if err := DecodeContactProperties(contact.Metadata, &typed.Properties); err != nil {
+		return nil, err
+	}
+	return typed, nil
+}
+
+// CreateTypedContact creates a contact with properties encoded from props.
+// Any Properties already set on payload are replaced.
+func CreateTypedContact[T any](client *Client, bookID string, payload CreateContactJSONBody, props T) (*ContactCreateResponse, error) {
+	book, apiErr := client.ContactBooks.Get(bookID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	encoded, err := EncodeContactProperties(props, book.Properties)
+	if err != nil {
+		return nil, err
+	}
+	payload.Properties = &encoded
+	resp, apiErr := client.Contacts.Create(bookID, payload)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	return resp, nil
+}
+
+// UpdateTypedContact updates a contact with properties encoded from props.
+// Any Properties already set on payload are replaced.
+func UpdateTypedContact[T any](client *Client, bookID, contactID string, payload UpdateContactJSONBody, props T) (*ContactUpdateResponse, error) {
+	book, apiErr := client.ContactBooks.Get(bookID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	encoded, err := EncodeContactProperties(props, book.Properties)
+	if err != nil {
+		return nil, err
+	}
+	payload.Properties = &encoded
+	resp, apiErr := client.Contacts.Update(bookID, contactID, payload)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	return resp, nil
+}
+
+// EncodeContactProperties converts a struct (or pointer to struct) into the
+// string map used by contact create and update bodies. When declared is not
+// nil, every mapped field must be declared on the contact book and its value
+// must match the declared property type. Nil pointer fields are omitted.
+func EncodeContactProperties(v interface{}, declared map[string]string) (map[string]string, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil, fmt.Errorf("cannot encode nil %s", rv.Type())
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("cannot encode %s as contact properties, expected a struct", rv.Type())
+	}
+	if declared != nil {
+		if err := checkDeclaredProperties(rv.Type(), declared); err != nil {
+			return nil, err
+		}
+	}
+
+	props := make(map[string]string)
+	for _, field := range propertyFields(rv.Type()) {
+		value, ok, err := encodePropertyValue(rv.Field(field.index))
+		if err != nil {
+			return nil, fmt.Errorf("property %q: %w", field.name, err)
+		}
+		if !ok {
+			continue
+		}
+		if declared != nil {
+			if err := validatePropertyValue(declared[field.name], value); err != nil {
+				return nil, fmt.Errorf("property %q: %w", field.name, err)
+			}
+		}
+		props[field.name] = value
+	}
+	return props, nil
+}
+
+// DecodeContactProperties decodes contact metadata into the struct pointed to by dst.
+// Properties without a matching field are ignored; missing properties leave the field untouched.
+func DecodeContactProperties(props map[string]interface{}, dst interface{}) error {
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("cannot decode contact properties into %T, expected a pointer to a struct", dst)
+	}
+	rv = rv.Elem()
+	for _, field := range propertyFields(rv.Type()) {
+		raw, ok := props[field.name]
+		if !ok || raw == nil {
+			continue
+		}
+		if err := decodePropertyValue(raw, rv.Field(field.index)); err != nil {
+			return fmt.Errorf("property %q: %w", field.name, err)
+		}
+	}
+	return nil
+}
+
+type propertyField struct {
+	index int
+	name  string
+}
+
+// propertyFields lists the exported fields of t with their property names
+func propertyFields(t reflect.Type) []propertyField {
+	var fields []propertyField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("unsent"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			r, size := utf8.DecodeRuneInString(f.Name)
+			name = string(unicode.ToLower(r)) + f.Name[size:]
+		}
+		fields = append(fields, propertyField{index: i, name: name})
+	}
+	return fields
+}
+
+// checkDeclaredProperties ensures every field of t maps to a property declared on the book
+func checkDeclaredProperties(t reflect.Type, declared map[string]string) error {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return fmt.Errorf("contact properties must be a struct, got %s", t)
+	}
+	for _, field := range propertyFields(t) {
+		if _, ok := declared[field.name]; !ok {
+			return fmt.Errorf("field %s.%s maps to property %q which is not declared on the contact book", t.Name(), t.Field(field.index).Name, field.name)
+		}
+	}
+	return nil
+}
+
+var (
+	timeType            = reflect.TypeOf(time.Time{})
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// encodePropertyValue formats a field value, reporting false for nil pointers
+func encodePropertyValue(v reflect.Value) (string, bool, error) {
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return "", false, nil
+		}
+		v = v.Elem()
+	}
+	if v.Type() == timeType {
+		return v.Interface().(time.Time).Format(time.RFC3339), true, nil
+	}
+	if v.Type().Implements(textMarshalerType) {
+		text, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		return string(text), err == nil, err
+	}
+	if reflect.PointerTo(v.Type()).Implements(textMarshalerType) {
+		// MarshalText has a pointer receiver; copy v when it is not addressable
+		ptr := reflect.New(v.Type())
+		ptr.Elem().Set(v)
+		text, err := ptr.Interface().(encoding.TextMarshaler).MarshalText()
+		return string(text), err == nil, err
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), true, nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), true, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), true, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), true, nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, v.Type().Bits()), true, nil
+	}
+	return "", false, fmt.Errorf("unsupported type %s", v.Type())
+}
+
+// decodePropertyValue stores a decoded JSON value (string, float64 or bool) into a field
+func decodePropertyValue(raw interface{}, v reflect.Value) error {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return decodePropertyValue(raw, v.Elem())
+	}
+
+	text, isText := raw.(string)
+	if v.Type() == timeType {
+		if !isText {
+			return fmt.Errorf("cannot decode %T into time.Time", raw)
+		}
+		t, err := time.Parse(time.RFC3339, text)
+		if err != nil {
+			if t, err = time.Parse(time.DateOnly, text); err != nil {
+				return fmt.Errorf("%q is not a date", text)
+			}
+		}
+		v.Set(reflect.ValueOf(t))
+		return nil
+	}
+	if reflect.PointerTo(v.Type()).Implements(textUnmarshalerType) {
+		if !isText {
+			text = fmt.Sprint(raw)
+		}
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(text))
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		if isText {
+			v.SetString(text)
+		} else {
+			v.SetString(fmt.Sprint(raw))
+		}
+	case reflect.Bool:
+		switch b := raw.(type) {
+		case bool:
+			v.SetBool(b)
+		case string:
+			parsed, ok := parseBoolish(b)
+			if !ok {
+				return fmt.Errorf("%q is not a boolean", b)
+			}
+			v.SetBool(parsed)
+		default:
+			return fmt.Errorf("cannot decode %T into bool", raw)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := propertyNumber(raw)
+		if err != nil {
+			return err
+		}
+		// converting a float outside the int64 range is implementation-defined
+		if n != math.Trunc(n) || n < -(1<<63) || n >= 1<<63 || v.OverflowInt(int64(n)) {
+			return fmt.Errorf("%v does not fit in %s", raw, v.Type())
+		}
+		v.SetInt(int64(n))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := propertyNumber(raw)
+		if err != nil {
+			return err
+		}
+		if n < 0 || n != math.Trunc(n) || n >= 1<<64 || v.OverflowUint(uint64(n)) {
+			return fmt.Errorf("%v does not fit in %s", raw, v.Type())
+		}
+		v.SetUint(uint64(n))
+	case reflect.Float32, reflect.Float64:
+		n, err := propertyNumber(raw)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(n)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+func propertyNumber(raw interface{}) (float64, error) {
+	switch n := raw.(type) {
+	case float64:
+		return n, nil
+	case string:
+		f, err := strconv.ParseFloat(n, 64)
+		if err != nil {
+			return 0, fmt.Errorf("%q is not a number", n)
+		}
+		return f, nil
+	}
+	return 0, fmt.Errorf("cannot decode %T into a number", raw)
+}
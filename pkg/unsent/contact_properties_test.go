@@ -0,0 +1,152 @@
+package unsent
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testPlan int
+
+const (
+	testPlanFree testPlan = iota
+	testPlanPro
+)
+
+func (p testPlan) MarshalText() ([]byte, error) {
+	return []byte([]string{"free", "pro"}[p]), nil
+}
+
+func (p *testPlan) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "free":
+		*p = testPlanFree
+	case "pro":
+		*p = testPlanPro
+	default:
+		return fmt.Errorf("unknown plan %q", text)
+	}
+	return nil
+}
+
+type testContactProps struct {
+	Plan     testPlan   `unsent:"plan"`
+	Seats    int        `unsent:"seats"`
+	Beta     bool       `unsent:"beta"`
+	Renewal  *time.Time `unsent:"renewsAt"`
+	Company  string
+	internal string
+	Ignored  string `unsent:"-"`
+}
+
+func TestEncodeContactProperties(t *testing.T) {
+	renewal := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	props, err := EncodeContactProperties(testContactProps{Plan: testPlanPro, Seats: 5, Beta: true, Renewal: &renewal, Company: "Acme"}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := map[string]string{"plan": "pro", "seats": "5", "beta": "true", "renewsAt": "2025-03-01T00:00:00Z", "company": "Acme"}
+	for key, value := range expected {
+		if props[key] != value {
+			t.Errorf("expected %s=%s, got %q", key, value, props[key])
+		}
+	}
+	if len(props) != len(expected) {
+		t.Errorf("unexpected properties: %v", props)
+	}
+
+	_, err = EncodeContactProperties(testContactProps{}, map[string]string{"plan": "string"})
+	if err == nil || !strings.Contains(err.Error(), "not declared") {
+		t.Errorf("expected undeclared property error, got %v", err)
+	}
+}
+
+func TestDecodeContactProperties(t *testing.T) {
+	var props testContactProps
+	err := DecodeContactProperties(map[string]interface{}{
+		"plan": "pro", "seats": float64(12), "beta": "yes", "renewsAt": "2025-03-01", "company": "Acme", "other": 1.5,
+	}, &props)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if props.Plan != testPlanPro || props.Seats != 12 || !props.Beta || props.Company != "Acme" {
+		t.Errorf("unexpected properties: %+v", props)
+	}
+	if props.Renewal == nil || props.Renewal.Day() != 1 {
+		t.Errorf("unexpected renewal: %v", props.Renewal)
+	}
+
+	if err := DecodeContactProperties(map[string]interface{}{"seats": 1.5}, &props); err == nil {
+		t.Error("expected error for fractional int")
+	}
+	for _, huge := range []float64{1e19, -1e19, math.Inf(1)} {
+		if err := DecodeContactProperties(map[string]interface{}{"seats": huge}, &props); err == nil {
+			t.Errorf("expected error for %v, got seats %d", huge, props.Seats)
+		}
+	}
+}
+
+type testRegion struct{ code string }
+
+func (r *testRegion) MarshalText() ([]byte, error) {
+	return []byte(strings.ToUpper(r.code)), nil
+}
+
+func TestEncodeContactProperties_PointerMarshaler(t *testing.T) {
+	props, err := EncodeContactProperties(struct {
+		Region testRegion `unsent:"region"`
+	}{testRegion{"eu"}}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if props["region"] != "EU" {
+		t.Errorf("expected the pointer-receiver MarshalText to be used, got %v", props)
+	}
+}
+
+func TestContactsOf(t *testing.T) {
+	var created CreateContactJSONBody
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/v1/contactBooks/book1":
+			w.Write([]byte(`{"id": "book1", "properties": {"plan": "string", "seats": "number", "beta": "boolean", "renewsAt": "date", "company": "string"}}`))
+		case r.Method == "GET" && !strings.HasPrefix(r.URL.Query().Get("page"), "1"):
+			w.Write([]byte(`[]`))
+		case r.Method == "GET":
+			w.Write([]byte(`[{"id": "c1", "email": "a@example.com", "metadata": {"plan": "free", "seats": 3}}]`))
+		default:
+			json.NewDecoder(r.Body).Decode(&created)
+			w.Write([]byte(`{"contactId": "c2"}`))
+		}
+	}))
+	defer server.Close()
+
+	client, _ := NewClient("key", WithBaseURL(server.URL))
+	contacts, err := ContactsOf[testContactProps](client, "book1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(contacts) != 1 || contacts[0].Email != "a@example.com" || contacts[0].Properties.Seats != 3 {
+		t.Errorf("unexpected contacts: %+v", contacts)
+	}
+
+	_, err = CreateTypedContact(client, "book1", CreateContactJSONBody{Email: "b@example.com"}, testContactProps{Plan: testPlanPro, Seats: 2})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if (*created.Properties)["plan"] != "pro" || (*created.Properties)["seats"] != "2" {
+		t.Errorf("unexpected properties: %v", *created.Properties)
+	}
+
+	type undeclared struct {
+		Region string `unsent:"region"`
+	}
+	if _, err := ContactsOf[undeclared](client, "book1"); err == nil {
+		t.Error("expected error for undeclared field")
+	}
+}
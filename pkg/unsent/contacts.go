@@ -49,3 +49,26 @@ func (c *ContactsClient) Upsert(bookID, contactID string, payload UpsertContactJ
 func (c *ContactsClient) Delete(bookID, contactID string) (*ContactDeleteResponse, *APIError) {
 	return Delete[ContactDeleteResponse](c.client, fmt.Sprintf("/contactBooks/%s/contacts/%s", bookID, contactID), nil)
 }
+
+// ListAll retrieves every contact in a contact book by walking the pages of List
+// until one comes back empty, so a server that caps the page size below
+// pageSize still returns every contact. A pageSize of zero uses 100 contacts
+// per page.
+func (c *ContactsClient) ListAll(bookID string, pageSize int) ([]Contact, *APIError) {
+	if pageSize <= 0 {
+		pageSize = 100
+	}
+	limit := float32(pageSize)
+	var contacts []Contact
+	for page := 1; ; page++ {
+		p := float32(page)
+		resp, err := c.List(bookID, GetContactsParams{Page: &p, Limit: &limit})
+		if err != nil {
+			return nil, err
+		}
+		if len(*resp) == 0 {
+			return contacts, nil
+		}
+		contacts = append(contacts, *resp...)
+	}
+}
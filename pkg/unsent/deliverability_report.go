@@ -0,0 +1,448 @@
+package unsent
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ReportSeverity ranks report findings
+type ReportSeverity string
+
+const (
+	SeverityInfo     ReportSeverity = "info"
+	SeverityWarning  ReportSeverity = "warning"
+	SeverityCritical ReportSeverity = "critical"
+)
+
+func (s ReportSeverity) rank() int {
+	switch s {
+	case SeverityCritical:
+		return 2
+	case SeverityWarning:
+		return 1
+	}
+	return 0
+}
+
+// ReportFinding is a rule that fired for the account or one domain
+type ReportFinding struct {
+	Rule     string         `json:"rule"`
+	Severity ReportSeverity `json:"severity"`
+	// Domain is empty for account-wide findings
+	Domain    string  `json:"domain,omitempty"`
+	Message   string  `json:"message"`
+	Value     float64 `json:"value"`
+	Threshold float64 `json:"threshold"`
+}
+
+// DomainHealth summarizes one sending domain over the report range
+type DomainHealth struct {
+	DomainID      string  `json:"domainId"`
+	Domain        string  `json:"domain"`
+	Sent          int     `json:"sent"`
+	Delivered     int     `json:"delivered"`
+	Bounced       int     `json:"bounced"`
+	Complained    int     `json:"complained"`
+	BounceRate    float64 `json:"bounceRate"`
+	ComplaintRate float64 `json:"complaintRate"`
+	// Reputation is nil when the score could not be read
+	Reputation *int `json:"reputation,omitempty"`
+	// StatsError is set when the counts could not be read from the domain
+	// stats, which then skip the rate rules and raise a domain_stats finding
+	StatsError string `json:"statsError,omitempty"`
+}
+
+// DeliverabilityReport is the account's deliverability over a time range
+type DeliverabilityReport struct {
+	From        time.Time `json:"from"`
+	To          time.Time `json:"to"`
+	GeneratedAt time.Time `json:"generatedAt"`
+	Stats       Stats     `json:"stats"`
+	// Metrics are the rates reported by the API for the period closest to the range
+	Metrics       Metrics               `json:"metrics"`
+	BounceRate    float64               `json:"bounceRate"`
+	ComplaintRate float64               `json:"complaintRate"`
+	Daily         []AnalyticsTimeSeries `json:"daily"`
+	Domains       []DomainHealth        `json:"domains"`
+	Findings      []ReportFinding       `json:"findings"`
+}
+
+// DeliverabilityRule inspects a report and returns extra findings
+type DeliverabilityRule func(report *DeliverabilityReport) []ReportFinding
+
+// DeliverabilityRules are the thresholds of the built-in rules. A rule
+// reports a warning above its threshold and is critical above twice it.
+type DeliverabilityRules struct {
+	// MaxBounceRate defaults to 0.02 (2%)
+	MaxBounceRate float64
+	// MaxComplaintRate defaults to 0.001 (0.1%)
+	MaxComplaintRate float64
+	// MinReputation flags domains scoring below it, defaults to 70
+	MinReputation int
+	// MaxReputationDrop flags domains whose score fell by more than this
+	// since the previous report, defaults to 10
+	MaxReputationDrop int
+	// MaxDelayedShare flags the latest complete day when this share of sent
+	// email is neither delivered, bounced nor failed and is above the range
+	// average, defaults to 0.05. The current day is still in progress, so it
+	// is left out.
+	MaxDelayedShare float64
+	// MinSent skips rate rules for volumes too small to be meaningful, defaults to 100
+	MinSent int
+	// Extra rules run after the built-in ones
+	Extra []DeliverabilityRule
+}
+
+func (r *DeliverabilityRules) setDefaults() {
+	if r.MaxBounceRate <= 0 {
+		r.MaxBounceRate = 0.02
+	}
+	if r.MaxComplaintRate <= 0 {
+		r.MaxComplaintRate = 0.001
+	}
+	if r.MinReputation <= 0 {
+		r.MinReputation = 70
+	}
+	if r.MaxReputationDrop <= 0 {
+		r.MaxReputationDrop = 10
+	}
+	if r.MaxDelayedShare <= 0 {
+		r.MaxDelayedShare = 0.05
+	}
+	if r.MinSent <= 0 {
+		r.MinSent = 100
+	}
+}
+
+// DeliverabilityReportOptions configures AnalyticsClient.DeliverabilityReport
+type DeliverabilityReportOptions struct {
+	// From and To bound the report, defaulting to the last 7 days
+	From time.Time
+	To   time.Time
+	// Domains limits the per-domain section to these domain names, defaults to every domain
+	Domains []string
+	Rules   DeliverabilityRules
+	// Previous is last period's report, used to detect reputation drops
+	Previous *DeliverabilityReport
+}
+
+// DeliverabilityReport assembles stats, metrics, daily series, per-domain
+// stats and reputation for a time range and evaluates the rules against them
+func (c *AnalyticsClient) DeliverabilityReport(opts DeliverabilityReportOptions) (*DeliverabilityReport, error) {
+	now := time.Now().UTC()
+	if opts.To.IsZero() {
+		opts.To = now
+	}
+	if opts.From.IsZero() {
+		opts.From = opts.To.Add(-7 * 24 * time.Hour)
+	}
+	if !opts.From.Before(opts.To) {
+		return nil, fmt.Errorf("report range %s to %s is empty", opts.From.Format(time.RFC3339), opts.To.Format(time.RFC3339))
+	}
+	opts.Rules.setDefaults()
+	from, to := opts.From.UTC(), opts.To.UTC()
+	report := &DeliverabilityReport{From: from, To: to, GeneratedAt: now}
+
+	stats, apiErr := c.client.Stats.Get(GetStatsParams{StartDate: &from, EndDate: &to})
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	report.Stats = stats.Data
+	report.BounceRate = ratio(report.Stats.Bounced, report.Stats.Sent)
+	report.ComplaintRate = ratio(report.Stats.Complained, report.Stats.Delivered)
+
+	period := metricsPeriodFor(to.Sub(from))
+	metrics, apiErr := c.client.Metrics.Get(GetMetricsParams{Period: &period})
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	report.Metrics = metrics.Data
+
+	days := strconv.Itoa(int(math.Ceil(now.Sub(from).Hours() / 24)))
+	series, apiErr := c.GetTimeSeries(GetTimeSeriesParams{Days: &days})
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	for _, day := range series.Data {
+		date, err := parseSeriesDate(day.Date)
+		if err != nil || date.Before(from.Truncate(24*time.Hour)) || !date.Before(to) {
+			continue
+		}
+		report.Daily = append(report.Daily, day)
+	}
+	sort.Slice(report.Daily, func(i, j int) bool { return report.Daily[i].Date < report.Daily[j].Date })
+
+	domains, apiErr := c.client.Domains.List()
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	wanted := make(map[string]bool)
+	for _, name := range opts.Domains {
+		wanted[strings.ToLower(name)] = true
+	}
+	start, end := from.Format(time.RFC3339), to.Format(time.RFC3339)
+	for _, domain := range *domains {
+		if len(wanted) > 0 && !wanted[strings.ToLower(domain.Domain)] {
+			continue
+		}
+		raw, apiErr := c.client.Domains.GetStats(domain.ID, GetDomainStatsParams{StartDate: &start, EndDate: &end})
+		if apiErr != nil {
+			return nil, apiErr
+		}
+		health, err := decodeDomainHealth(raw)
+		if err != nil {
+			health.StatsError = err.Error()
+		}
+		health.DomainID, health.Domain = domain.ID, domain.Domain
+		health.BounceRate = ratio(health.Bounced, health.Sent)
+		health.ComplaintRate = ratio(health.Complained, health.Delivered)
+		name := domain.Domain
+		if rep, apiErr := c.GetReputation(GetReputationParams{Domain: &name}); apiErr == nil {
+			score := rep.Reputation
+			health.Reputation = &score
+		}
+		report.Domains = append(report.Domains, health)
+	}
+
+	report.Findings = evaluateDeliverability(report, opts.Rules, opts.Previous)
+	return report, nil
+}
+
+// evaluateDeliverability runs the built-in and extra rules, most severe first
+func evaluateDeliverability(report *DeliverabilityReport, rules DeliverabilityRules, previous *DeliverabilityReport) []ReportFinding {
+	var findings []ReportFinding
+	add := func(rule, domain string, value, threshold float64, message string) {
+		severity := SeverityWarning
+		if value > 2*threshold {
+			severity = SeverityCritical
+		}
+		findings = append(findings, ReportFinding{Rule: rule, Severity: severity, Domain: domain, Message: message, Value: value, Threshold: threshold})
+	}
+	rateRules := func(domain string, sent int, bounceRate, complaintRate float64) {
+		if sent < rules.MinSent {
+			return
+		}
+		if bounceRate > rules.MaxBounceRate {
+			add("bounce_rate", domain, bounceRate, rules.MaxBounceRate,
+				fmt.Sprintf("bounce rate %s exceeds %s", percent(bounceRate), percent(rules.MaxBounceRate)))
+		}
+		if complaintRate > rules.MaxComplaintRate {
+			add("complaint_rate", domain, complaintRate, rules.MaxComplaintRate,
+				fmt.Sprintf("complaint rate %s exceeds %s", percent(complaintRate), percent(rules.MaxComplaintRate)))
+		}
+	}
+
+	rateRules("", report.Stats.Sent, report.BounceRate, report.ComplaintRate)
+
+	previousScores := make(map[string]int)
+	if previous != nil {
+		for _, domain := range previous.Domains {
+			if domain.Reputation != nil {
+				previousScores[domain.Domain] = *domain.Reputation
+			}
+		}
+	}
+	for _, domain := range report.Domains {
+		if domain.StatsError != "" {
+			findings = append(findings, ReportFinding{
+				Rule: "domain_stats", Severity: SeverityWarning, Domain: domain.Domain,
+				Message: "domain stats could not be read: " + domain.StatsError,
+			})
+		} else {
+			rateRules(domain.Domain, domain.Sent, domain.BounceRate, domain.ComplaintRate)
+		}
+		if domain.Reputation == nil {
+			continue
+		}
+		score := *domain.Reputation
+		if score < rules.MinReputation {
+			severity := SeverityWarning
+			if score < rules.MinReputation/2 {
+				severity = SeverityCritical
+			}
+			findings = append(findings, ReportFinding{
+				Rule: "reputation", Severity: severity, Domain: domain.Domain,
+				Message: fmt.Sprintf("reputation %d is below %d", score, rules.MinReputation),
+				Value:   float64(score), Threshold: float64(rules.MinReputation),
+			})
+		}
+		if before, ok := previousScores[domain.Domain]; ok && before-score > rules.MaxReputationDrop {
+			add("reputation_drop", domain.Domain, float64(before-score), float64(rules.MaxReputationDrop),
+				fmt.Sprintf("reputation fell from %d to %d", before, score))
+		}
+	}
+
+	var complete []AnalyticsTimeSeries
+	for _, day := range report.Daily {
+		if date, err := parseSeriesDate(day.Date); err == nil && !date.Add(24*time.Hour).After(report.GeneratedAt) {
+			complete = append(complete, day)
+		}
+	}
+	if n := len(complete); n > 1 {
+		var total float64
+		var counted int
+		for _, day := range complete {
+			if day.Sent > 0 {
+				total += delayedShare(day)
+				counted++
+			}
+		}
+		latest := complete[n-1]
+		if share := delayedShare(latest); latest.Sent >= rules.MinSent && share > rules.MaxDelayedShare && share > total/float64(counted) {
+			add("delivery_delays", "", share, rules.MaxDelayedShare,
+				fmt.Sprintf("%s of email sent on %s is still undelivered, up from a %s average", percent(share), latest.Date, percent(total/float64(counted))))
+		}
+	}
+
+	for _, rule := range rules.Extra {
+		findings = append(findings, rule(report)...)
+	}
+	sort.SliceStable(findings, func(i, j int) bool {
+		return findings[i].Severity.rank() > findings[j].Severity.rank()
+	})
+	return findings
+}
+
+// delayedShare is the share of a day's sent email not yet delivered, bounced or failed
+func delayedShare(day AnalyticsTimeSeries) float64 {
+	pending := day.Sent - day.Delivered - day.Bounced - day.Failed
+	if pending < 0 {
+		pending = 0
+	}
+	return ratio(pending, day.Sent)
+}
+
+// Severity returns the most severe finding's severity, or info when there are none
+func (r *DeliverabilityReport) Severity() ReportSeverity {
+	severity := SeverityInfo
+	for _, finding := range r.Findings {
+		if finding.Severity.rank() > severity.rank() {
+			severity = finding.Severity
+		}
+	}
+	return severity
+}
+
+// WriteJSON writes the report as indented JSON
+func (r *DeliverabilityReport) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+// Markdown renders the report for a weekly review document
+func (r *DeliverabilityReport) Markdown() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Deliverability report\n\n%s to %s (status: %s)\n\n",
+		r.From.Format(time.DateOnly), r.To.Format(time.DateOnly), r.Severity())
+
+	b.WriteString("| Sent | Delivered | Bounced | Complained | Bounce rate | Complaint rate | Open rate | Click rate |\n")
+	b.WriteString("|---:|---:|---:|---:|---:|---:|---:|---:|\n")
+	fmt.Fprintf(&b, "| %d | %d | %d | %d | %s | %s | %s | %s |\n\n",
+		r.Stats.Sent, r.Stats.Delivered, r.Stats.Bounced, r.Stats.Complained,
+		percent(r.BounceRate), percent(r.ComplaintRate), percent(r.Metrics.OpenRate), percent(r.Metrics.ClickRate))
+
+	b.WriteString("## Findings\n\n")
+	if len(r.Findings) == 0 {
+		b.WriteString("No rules fired.\n")
+	}
+	for _, finding := range r.Findings {
+		scope := "account"
+		if finding.Domain != "" {
+			scope = finding.Domain
+		}
+		fmt.Fprintf(&b, "- **%s** %s (%s): %s\n", finding.Severity, finding.Rule, scope, finding.Message)
+	}
+
+	if len(r.Domains) > 0 {
+		b.WriteString("\n## Domains\n\n| Domain | Sent | Delivered | Bounce rate | Complaint rate | Reputation |\n|---|---:|---:|---:|---:|---:|\n")
+		for _, d := range r.Domains {
+			reputation := "n/a"
+			if d.Reputation != nil {
+				reputation = strconv.Itoa(*d.Reputation)
+			}
+			fmt.Fprintf(&b, "| %s | %d | %d | %s | %s | %s |\n", d.Domain, d.Sent, d.Delivered, percent(d.BounceRate), percent(d.ComplaintRate), reputation)
+		}
+	}
+
+	if len(r.Daily) > 0 {
+		b.WriteString("\n## Daily\n\n| Date | Sent | Delivered | Bounced | Complained |\n|---|---:|---:|---:|---:|\n")
+		for _, day := range r.Daily {
+			fmt.Fprintf(&b, "| %s | %d | %d | %d | %d |\n", day.Date, day.Sent, day.Delivered, day.Bounced, day.Complained)
+		}
+	}
+	return b.String()
+}
+
+// decodeDomainHealth reads counts from the untyped Domains.GetStats
+// response, which may nest them under "data" or "stats". It fails when the
+// sent count is missing or a count is not a number, rather than reporting
+// zeros for a response shape it does not know.
+func decodeDomainHealth(raw *interface{}) (DomainHealth, error) {
+	var health DomainHealth
+	if raw == nil {
+		return health, fmt.Errorf("empty response")
+	}
+	values, ok := (*raw).(map[string]interface{})
+	if !ok {
+		return health, fmt.Errorf("unexpected response %T", *raw)
+	}
+	for _, key := range []string{"data", "stats"} {
+		if nested, ok := values[key].(map[string]interface{}); ok {
+			values = nested
+			break
+		}
+	}
+	if _, ok := values["sent"]; !ok {
+		return health, fmt.Errorf("no sent count in response")
+	}
+	var err error
+	count := func(key string) int {
+		value, ok := values[key]
+		if !ok {
+			return 0
+		}
+		n, isNumber := value.(float64)
+		if !isNumber && err == nil {
+			err = fmt.Errorf("%s count is %T, not a number", key, value)
+		}
+		return int(n)
+	}
+	health.Sent = count("sent")
+	health.Delivered = count("delivered")
+	health.Bounced = count("bounced")
+	health.Complained = count("complained")
+	if err != nil {
+		return DomainHealth{}, err
+	}
+	return health, nil
+}
+
+// parseSeriesDate parses the date of a time-series row, "2006-01-02" or RFC 3339
+func parseSeriesDate(value string) (time.Time, error) {
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+// metricsPeriodFor picks the smallest Metrics.Get period that covers d
+func metricsPeriodFor(d time.Duration) GetMetricsParamsPeriod {
+	switch {
+	case d <= 24*time.Hour:
+		return GetMetricsParamsPeriodDay
+	case d <= 7*24*time.Hour:
+		return GetMetricsParamsPeriodWeek
+	}
+	return GetMetricsParamsPeriodMonth
+}
+
+func percent(rate float64) string {
+	return strconv.FormatFloat(rate*100, 'f', 2, 64) + "%"
+}
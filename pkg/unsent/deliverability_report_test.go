@@ -0,0 +1,158 @@
+package unsent
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newDeliverabilityServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/stats":
+			if r.URL.Query().Get("startDate") != "2025-01-08T00:00:00Z" {
+				t.Errorf("unexpected stats range %s", r.URL.RawQuery)
+			}
+			w.Write([]byte(`{"data": {"sent": 5000, "delivered": 4700, "bounced": 150, "complained": 2}}`))
+		case "/v1/metrics":
+			if r.URL.Query().Get("period") != "week" {
+				t.Errorf("expected period=week, got %s", r.URL.RawQuery)
+			}
+			w.Write([]byte(`{"data": {"deliveryRate": 0.94, "openRate": 0.31, "clickRate": 0.04}}`))
+		case "/v1/analytics/time-series":
+			var days []string
+			for d := 7; d <= 14; d++ {
+				sent, delivered := 600, 590
+				if d == 14 {
+					sent, delivered = 1000, 850
+				}
+				days = append(days, fmt.Sprintf(`{"date": "2025-01-%02d", "sent": %d, "delivered": %d, "bounced": 10}`, d, sent, delivered))
+			}
+			w.Write([]byte(`{"data": [` + strings.Join(days, ",") + `]}`))
+		case "/v1/domains":
+			w.Write([]byte(`[{"id": "d1", "name": "a.com"}, {"id": "d2", "name": "b.com"}]`))
+		case "/v1/domains/d1/stats":
+			w.Write([]byte(`{"data": {"sent": 2000, "delivered": 1890, "bounced": 100, "complained": 1}}`))
+		case "/v1/domains/d2/stats":
+			w.Write([]byte(`{"sent": 50, "delivered": 40, "bounced": 10}`))
+		case "/v1/analytics/reputation":
+			score := map[string]string{"a.com": "60", "b.com": "95"}[r.URL.Query().Get("domain")]
+			w.Write([]byte(`{"reputation": ` + score + `}`))
+		default:
+			t.Errorf("unexpected request %s", r.URL.Path)
+		}
+	}))
+}
+
+func TestAnalytics_DeliverabilityReport(t *testing.T) {
+	server := newDeliverabilityServer(t)
+	defer server.Close()
+	client, _ := NewClient("key", WithBaseURL(server.URL))
+
+	lastWeek := 80
+	report, err := client.Analytics.DeliverabilityReport(DeliverabilityReportOptions{
+		From:     time.Date(2025, 1, 8, 0, 0, 0, 0, time.UTC),
+		To:       time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC),
+		Previous: &DeliverabilityReport{Domains: []DomainHealth{{Domain: "a.com", Reputation: &lastWeek}}},
+		Rules: DeliverabilityRules{Extra: []DeliverabilityRule{func(r *DeliverabilityReport) []ReportFinding {
+			return []ReportFinding{{Rule: "open_rate", Severity: SeverityInfo, Message: "open rate is healthy"}}
+		}}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(report.Daily) != 7 || report.Daily[0].Date != "2025-01-08" {
+		t.Errorf("expected 7 days from 2025-01-08, got %+v", report.Daily)
+	}
+	if len(report.Domains) != 2 || report.Domains[0].Sent != 2000 || report.Domains[1].Bounced != 10 {
+		t.Errorf("unexpected domains: %+v", report.Domains)
+	}
+
+	type key struct {
+		rule, domain string
+		severity     ReportSeverity
+	}
+	got := make(map[key]bool)
+	for _, f := range report.Findings {
+		got[key{f.Rule, f.Domain, f.Severity}] = true
+	}
+	want := []key{
+		{"bounce_rate", "", SeverityWarning},
+		{"bounce_rate", "a.com", SeverityCritical},
+		{"reputation", "a.com", SeverityWarning},
+		{"reputation_drop", "a.com", SeverityWarning},
+		{"delivery_delays", "", SeverityCritical},
+		{"open_rate", "", SeverityInfo},
+	}
+	for _, k := range want {
+		if !got[k] {
+			t.Errorf("expected finding %+v, got %+v", k, report.Findings)
+		}
+	}
+	if len(report.Findings) != len(want) {
+		t.Errorf("expected %d findings, got %+v", len(want), report.Findings)
+	}
+	if report.Findings[0].Severity != SeverityCritical || report.Findings[len(report.Findings)-1].Severity != SeverityInfo {
+		t.Errorf("expected findings ordered by severity, got %+v", report.Findings)
+	}
+	if report.Severity() != SeverityCritical {
+		t.Errorf("expected critical report, got %s", report.Severity())
+	}
+
+	markdown := report.Markdown()
+	for _, line := range []string{"2025-01-08 to 2025-01-15 (status: critical)", "- **critical** bounce_rate (a.com): bounce rate 5.00% exceeds 2.00%", "| b.com | 50 | 40 | 20.00% | 0.00% | 95 |"} {
+		if !strings.Contains(markdown, line) {
+			t.Errorf("expected markdown to contain %q, got:\n%s", line, markdown)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := report.WriteJSON(&buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var decoded DeliverabilityReport
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil || *decoded.Domains[0].Reputation != 60 || len(decoded.Findings) != len(want) {
+		t.Errorf("expected the report to round-trip through JSON, got %+v, %v", decoded, err)
+	}
+}
+
+func TestDecodeDomainHealth(t *testing.T) {
+	for _, body := range []string{`{"data": {"sent": 10, "delivered": 9}}`, `{"stats": {"sent": 0}}`} {
+		var raw interface{}
+		json.Unmarshal([]byte(body), &raw)
+		if _, err := decodeDomainHealth(&raw); err != nil {
+			t.Errorf("%s: unexpected error: %v", body, err)
+		}
+	}
+	for _, body := range []string{`{"data": {"total": 10}}`, `{"sent": "10"}`, `[1, 2]`} {
+		var raw interface{}
+		json.Unmarshal([]byte(body), &raw)
+		if _, err := decodeDomainHealth(&raw); err == nil {
+			t.Errorf("%s: expected an error", body)
+		}
+	}
+}
+
+func TestEvaluateDeliverability_SkipsIncompleteData(t *testing.T) {
+	report := &DeliverabilityReport{
+		GeneratedAt: time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC),
+		Daily: []AnalyticsTimeSeries{
+			{Date: "2025-01-13", Sent: 600, Delivered: 590},
+			{Date: "2025-01-14", Sent: 600, Delivered: 590},
+			// today is still in flight
+			{Date: "2025-01-15", Sent: 1000, Delivered: 500},
+		},
+		Domains: []DomainHealth{{Domain: "a.com", StatsError: "no sent count in response"}},
+	}
+	rules := DeliverabilityRules{}
+	rules.setDefaults()
+	findings := evaluateDeliverability(report, rules, nil)
+	if len(findings) != 1 || findings[0].Rule != "domain_stats" || findings[0].Domain != "a.com" {
+		t.Errorf("expected only a domain_stats finding, got %+v", findings)
+	}
+}
@@ -0,0 +1,205 @@
+package unsent
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// Kinds of DNS records published for a domain
+const (
+	DNSRecordSPF   = "SPF"
+	DNSRecordDKIM  = "DKIM"
+	DNSRecordDMARC = "DMARC"
+	DNSRecordMX    = "MX"
+	DNSRecordOther = "OTHER"
+)
+
+// Kind classifies the record as SPF, DKIM, DMARC, MX or OTHER
+func (r DomainDNSRecord) Kind() string {
+	name := strings.ToLower(r.Name)
+	switch {
+	case strings.EqualFold(r.Type, "MX"):
+		return DNSRecordMX
+	case strings.HasPrefix(name, "_dmarc"):
+		return DNSRecordDMARC
+	case strings.Contains(name, "._domainkey"):
+		return DNSRecordDKIM
+	case strings.EqualFold(r.Type, "TXT") && strings.HasPrefix(strings.ToLower(unquoteTXT(r.Value)), "v=spf1"):
+		return DNSRecordSPF
+	}
+	return DNSRecordOther
+}
+
+// FQDN returns the fully qualified record name for a domain. Record names
+// may be relative ("mail._domainkey"), apex ("@") or already qualified.
+func (r DomainDNSRecord) FQDN(domain string) string {
+	name := strings.TrimSuffix(strings.TrimSpace(r.Name), ".")
+	domain = strings.TrimSuffix(domain, ".")
+	switch {
+	case name == "" || name == "@":
+		return domain
+	case strings.EqualFold(name, domain) || strings.HasSuffix(strings.ToLower(name), "."+strings.ToLower(domain)):
+		return name
+	}
+	return name + "." + domain
+}
+
+// DNSResolver looks up the records checked by CheckDNSRecords.
+// *net.Resolver satisfies it; tests can supply a stub.
+type DNSResolver interface {
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+	LookupMX(ctx context.Context, name string) ([]*net.MX, error)
+	LookupCNAME(ctx context.Context, host string) (string, error)
+}
+
+// DNSRecordCheck is the result of checking a single record
+type DNSRecordCheck struct {
+	Record DomainDNSRecord
+	Kind   string
+	FQDN   string
+	Pass   bool
+	// Found lists the values published at the record name
+	Found []string
+	// Err is set when the lookup itself failed
+	Err error
+}
+
+// DNSCheckReport lists the checks performed for a domain
+type DNSCheckReport struct {
+	Domain string
+	Checks []DNSRecordCheck
+}
+
+// Passed reports whether every record was found with the expected value
+func (r *DNSCheckReport) Passed() bool {
+	for _, check := range r.Checks {
+		if !check.Pass {
+			return false
+		}
+	}
+	return len(r.Checks) > 0
+}
+
+// Failures returns the checks that did not pass
+func (r *DNSCheckReport) Failures() []DNSRecordCheck {
+	var failed []DNSRecordCheck
+	for _, check := range r.Checks {
+		if !check.Pass {
+			failed = append(failed, check)
+		}
+	}
+	return failed
+}
+
+// CheckDNSRecords resolves each record and compares the published value with
+// the expected one. SPF passes when every expected mechanism is published;
+// DMARC passes when any DMARC policy is published; other records must match
+// exactly (ignoring case, quotes and trailing dots). A nil resolver uses
+// net.DefaultResolver.
+func CheckDNSRecords(ctx context.Context, resolver DNSResolver, domain string, records []DomainDNSRecord) *DNSCheckReport {
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+	report := &DNSCheckReport{Domain: domain}
+	for _, record := range records {
+		check := DNSRecordCheck{Record: record, Kind: record.Kind(), FQDN: record.FQDN(domain)}
+		switch strings.ToUpper(record.Type) {
+		case "MX":
+			mxs, err := resolver.LookupMX(ctx, check.FQDN)
+			check.Err = err
+			for _, mx := range mxs {
+				check.Found = append(check.Found, fmt.Sprintf("%d %s", mx.Pref, mx.Host))
+				if sameHost(mx.Host, record.Value) && (record.Priority == nil || int(mx.Pref) == *record.Priority) {
+					check.Pass = true
+				}
+			}
+		case "CNAME":
+			target, err := resolver.LookupCNAME(ctx, check.FQDN)
+			check.Err = err
+			if err == nil {
+				check.Found = []string{target}
+				check.Pass = sameHost(target, record.Value)
+			}
+		default:
+			txts, err := resolver.LookupTXT(ctx, check.FQDN)
+			check.Err = err
+			check.Found = txts
+			for _, txt := range txts {
+				if txtMatches(check.Kind, txt, record.Value) {
+					check.Pass = true
+				}
+			}
+		}
+		report.Checks = append(report.Checks, check)
+	}
+	return report
+}
+
+// CheckDNS retrieves a domain and checks its DNS records with the resolver
+func (c *DomainsClient) CheckDNS(ctx context.Context, domainID string, resolver DNSResolver) (*DNSCheckReport, error) {
+	domain, apiErr := c.Get(domainID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if len(domain.DNSRecords) == 0 {
+		return nil, fmt.Errorf("domain %s has no DNS records to check", domain.Domain)
+	}
+	return CheckDNSRecords(ctx, resolver, domain.Domain, domain.DNSRecords), nil
+}
+
+// CheckAndVerify checks a domain's DNS records and only triggers Verify when
+// every record passes, so verification is not attempted before DNS has propagated
+func (c *DomainsClient) CheckAndVerify(ctx context.Context, domainID string, resolver DNSResolver) (*DNSCheckReport, *DomainVerifyResponse, error) {
+	report, err := c.CheckDNS(ctx, domainID, resolver)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !report.Passed() {
+		return report, nil, nil
+	}
+	verify, apiErr := c.Verify(domainID)
+	if apiErr != nil {
+		return report, nil, apiErr
+	}
+	return report, verify, nil
+}
+
+func txtMatches(kind, found, expected string) bool {
+	found, expected = normalizeTXT(found), normalizeTXT(expected)
+	switch kind {
+	case DNSRecordDMARC:
+		return strings.HasPrefix(found, "v=dmarc1")
+	case DNSRecordSPF:
+		if !strings.HasPrefix(found, "v=spf1") {
+			return false
+		}
+		published := make(map[string]bool)
+		for _, term := range strings.Fields(found) {
+			published[term] = true
+		}
+		for _, term := range strings.Fields(expected) {
+			if strings.HasSuffix(term, "all") {
+				continue
+			}
+			if !published[term] {
+				return false
+			}
+		}
+		return true
+	}
+	return strings.ReplaceAll(found, " ", "") == strings.ReplaceAll(expected, " ", "")
+}
+
+func normalizeTXT(value string) string {
+	return strings.ToLower(strings.Join(strings.Fields(unquoteTXT(value)), " "))
+}
+
+func unquoteTXT(value string) string {
+	return strings.ReplaceAll(strings.TrimSpace(value), "\"", "")
+}
+
+func sameHost(a, b string) bool {
+	return strings.EqualFold(strings.TrimSuffix(strings.TrimSpace(a), "."), strings.TrimSuffix(strings.TrimSpace(b), "."))
+}
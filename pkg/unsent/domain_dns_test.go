@@ -0,0 +1,112 @@
+package unsent
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type stubResolver struct {
+	txt   map[string][]string
+	mx    map[string][]*net.MX
+	cname map[string]string
+}
+
+func (s stubResolver) LookupTXT(_ context.Context, name string) ([]string, error) {
+	if v, ok := s.txt[name]; ok {
+		return v, nil
+	}
+	return nil, errors.New("no such host")
+}
+
+func (s stubResolver) LookupMX(_ context.Context, name string) ([]*net.MX, error) {
+	if v, ok := s.mx[name]; ok {
+		return v, nil
+	}
+	return nil, errors.New("no such host")
+}
+
+func (s stubResolver) LookupCNAME(_ context.Context, host string) (string, error) {
+	if v, ok := s.cname[host]; ok {
+		return v, nil
+	}
+	return "", errors.New("no such host")
+}
+
+const testDomainResponse = `{
+	"id": "dom1",
+	"name": "example.com",
+	"status": "PENDING",
+	"dnsRecords": [
+		{"type": "TXT", "name": "send", "value": "\"v=spf1 include:amazonses.com ~all\""},
+		{"type": "TXT", "name": "unsent._domainkey", "value": "p=MIGfMA0"},
+		{"type": "TXT", "name": "_dmarc", "value": "v=DMARC1; p=none;"},
+		{"type": "MX", "name": "send", "value": "feedback-smtp.us-east-1.amazonses.com", "priority": 10}
+	]
+}`
+
+func TestDomainDNSRecord_Kind(t *testing.T) {
+	cases := map[string]DomainDNSRecord{
+		DNSRecordSPF:   {Type: "TXT", Name: "send", Value: "v=spf1 -all"},
+		DNSRecordDKIM:  {Type: "TXT", Name: "unsent._domainkey", Value: "p=abc"},
+		DNSRecordDMARC: {Type: "TXT", Name: "_dmarc.example.com", Value: "v=DMARC1"},
+		DNSRecordMX:    {Type: "MX", Name: "send", Value: "mx.example.net"},
+	}
+	for kind, record := range cases {
+		if record.Kind() != kind {
+			t.Errorf("expected %s, got %s", kind, record.Kind())
+		}
+	}
+	if fqdn := (DomainDNSRecord{Name: "@"}).FQDN("example.com"); fqdn != "example.com" {
+		t.Errorf("expected apex, got %s", fqdn)
+	}
+	if fqdn := (DomainDNSRecord{Name: "_dmarc.example.com."}).FQDN("example.com"); fqdn != "_dmarc.example.com" {
+		t.Errorf("expected qualified name untouched, got %s", fqdn)
+	}
+}
+
+func TestDomains_CheckAndVerify(t *testing.T) {
+	verified := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/domains/dom1/verify" {
+			verified = true
+			w.Write([]byte(`{"id": "dom1", "status": "PENDING"}`))
+			return
+		}
+		w.Write([]byte(testDomainResponse))
+	}))
+	defer server.Close()
+	client, _ := NewClient("key", WithBaseURL(server.URL))
+
+	resolver := stubResolver{
+		txt: map[string][]string{
+			"send.example.com":              {"v=spf1 include:amazonses.com include:_spf.google.com ~all"},
+			"unsent._domainkey.example.com": {"p=MIGfMA0"},
+		},
+		mx: map[string][]*net.MX{"send.example.com": {{Host: "feedback-smtp.us-east-1.amazonses.com.", Pref: 10}}},
+	}
+
+	report, verify, err := client.Domains.CheckAndVerify(context.Background(), "dom1", resolver)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if report.Passed() || verify != nil || verified {
+		t.Fatal("expected verification to be skipped while DMARC is missing")
+	}
+	failures := report.Failures()
+	if len(failures) != 1 || failures[0].Kind != DNSRecordDMARC || failures[0].Err == nil {
+		t.Fatalf("unexpected failures: %+v", failures)
+	}
+
+	resolver.txt["_dmarc.example.com"] = []string{"v=DMARC1; p=quarantine; rua=mailto:d@example.com"}
+	report, verify, err = client.Domains.CheckAndVerify(context.Background(), "dom1", resolver)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !report.Passed() || verify == nil || !verified {
+		t.Errorf("expected all records to pass and verification to run, got %+v", report.Failures())
+	}
+}
@@ -0,0 +1,123 @@
+package unsent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DomainVerificationOutcome is the final state of WaitForVerification
+type DomainVerificationOutcome string
+
+const (
+	DomainVerificationVerified DomainVerificationOutcome = "verified"
+	DomainVerificationFailed   DomainVerificationOutcome = "failed"
+	DomainVerificationTimedOut DomainVerificationOutcome = "timedOut"
+)
+
+// DomainVerificationOptions configures DomainsClient.WaitForVerification
+type DomainVerificationOptions struct {
+	PollOptions
+	// Timeout bounds the wait in addition to the context deadline, defaults to 30 minutes
+	Timeout time.Duration
+	// OnStatusChange is called whenever the domain status changes
+	OnStatusChange func(from, to string, domain *Domain)
+	// MaxConsecutiveErrors stops the wait after this many failed polls in a row, defaults to 3
+	MaxConsecutiveErrors int
+}
+
+// DomainVerificationResult distinguishes verified, failed and timed-out domains
+type DomainVerificationResult struct {
+	Outcome DomainVerificationOutcome
+	Domain  *Domain
+	// Reasons explains a failed or timed-out verification using the record statuses
+	Reasons  []string
+	Attempts int
+	Elapsed  time.Duration
+}
+
+// WaitForVerification triggers verification for a domain and polls it until
+// the status becomes verified or failed. Running out of time is reported as
+// a DomainVerificationTimedOut outcome rather than an error; errors are returned
+// for failed API calls and when the caller cancels the context.
+func (c *DomainsClient) WaitForVerification(ctx context.Context, domainID string, opts DomainVerificationOptions) (*DomainVerificationResult, error) {
+	if opts.Timeout <= 0 {
+		opts.Timeout = 30 * time.Minute
+	}
+	if opts.MaxConsecutiveErrors <= 0 {
+		opts.MaxConsecutiveErrors = 3
+	}
+	started := time.Now()
+	waitCtx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	if _, apiErr := c.Verify(domainID); apiErr != nil {
+		return nil, apiErr
+	}
+
+	result := &DomainVerificationResult{}
+	wait := newBackoff(opts.PollOptions)
+	status := ""
+	failures := 0
+	for {
+		domain, apiErr := c.Get(domainID)
+		result.Attempts++
+		if apiErr != nil {
+			failures++
+			if failures >= opts.MaxConsecutiveErrors {
+				return result, apiErr
+			}
+		} else {
+			failures = 0
+			result.Domain = domain
+			if domain.Status != status {
+				if status != "" && opts.OnStatusChange != nil {
+					opts.OnStatusChange(status, domain.Status, domain)
+				}
+				status = domain.Status
+				wait.reset()
+			}
+			switch strings.ToUpper(domain.Status) {
+			case DomainStatusSuccess, "VERIFIED":
+				result.Outcome = DomainVerificationVerified
+				result.Elapsed = time.Since(started)
+				return result, nil
+			case DomainStatusFailed:
+				result.Outcome = DomainVerificationFailed
+				result.Reasons = domainVerificationReasons(domain)
+				result.Elapsed = time.Since(started)
+				return result, nil
+			}
+		}
+
+		if err := sleepContext(waitCtx, wait.next()); err != nil {
+			if ctx.Err() != nil && !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return result, ctx.Err()
+			}
+			result.Outcome = DomainVerificationTimedOut
+			result.Elapsed = time.Since(started)
+			if result.Domain != nil {
+				result.Reasons = domainVerificationReasons(result.Domain)
+			}
+			return result, nil
+		}
+	}
+}
+
+// domainVerificationReasons lists the records that have not verified
+func domainVerificationReasons(domain *Domain) []string {
+	var reasons []string
+	for _, record := range domain.DNSRecords {
+		status := strings.ToUpper(record.Status)
+		if status == "" || status == DomainStatusSuccess || status == "VERIFIED" {
+			continue
+		}
+		reasons = append(reasons, fmt.Sprintf("%s record %s is %s", record.Kind(), record.FQDN(domain.Domain), status))
+	}
+	if len(reasons) == 0 {
+		reasons = append(reasons, fmt.Sprintf("domain status is %s", domain.Status))
+	}
+	return reasons
+}
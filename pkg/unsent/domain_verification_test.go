@@ -0,0 +1,83 @@
+package unsent
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDomains_WaitForVerification(t *testing.T) {
+	statuses := []string{"NOT_STARTED", "PENDING", "PENDING", "SUCCESS"}
+	var polls int32
+	verifyCalled := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "PUT" && r.URL.Path == "/v1/domains/dom1/verify" {
+			verifyCalled = true
+			w.Write([]byte(`{"id": "dom1", "status": "PENDING"}`))
+			return
+		}
+		i := int(atomic.AddInt32(&polls, 1)) - 1
+		if i >= len(statuses) {
+			i = len(statuses) - 1
+		}
+		w.Write([]byte(`{"id": "dom1", "name": "example.com", "status": "` + statuses[i] + `"}`))
+	}))
+	defer server.Close()
+	client, _ := NewClient("key", WithBaseURL(server.URL))
+
+	var changes []string
+	result, err := client.Domains.WaitForVerification(context.Background(), "dom1", DomainVerificationOptions{
+		PollOptions:    PollOptions{Interval: time.Millisecond},
+		OnStatusChange: func(from, to string, _ *Domain) { changes = append(changes, from+"->"+to) },
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !verifyCalled || result.Outcome != DomainVerificationVerified || result.Attempts != 4 {
+		t.Errorf("unexpected result: %+v", result)
+	}
+	if len(changes) != 2 || changes[0] != "NOT_STARTED->PENDING" || changes[1] != "PENDING->SUCCESS" {
+		t.Errorf("unexpected status changes: %v", changes)
+	}
+}
+
+func TestDomains_WaitForVerificationFailedAndTimedOut(t *testing.T) {
+	status := "FAILED"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id": "dom1", "name": "example.com", "status": "` + status + `", "dnsRecords": [
+			{"type": "TXT", "name": "unsent._domainkey", "value": "p=abc", "status": "FAILED"},
+			{"type": "MX", "name": "send", "value": "mx.example.net", "status": "SUCCESS"}
+		]}`))
+	}))
+	defer server.Close()
+	client, _ := NewClient("key", WithBaseURL(server.URL))
+
+	result, err := client.Domains.WaitForVerification(context.Background(), "dom1", DomainVerificationOptions{PollOptions: PollOptions{Interval: time.Millisecond}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Outcome != DomainVerificationFailed || len(result.Reasons) != 1 || result.Reasons[0] != "DKIM record unsent._domainkey.example.com is FAILED" {
+		t.Errorf("unexpected result: %+v", result)
+	}
+
+	status = "PENDING"
+	result, err = client.Domains.WaitForVerification(context.Background(), "dom1", DomainVerificationOptions{
+		PollOptions: PollOptions{Interval: time.Millisecond},
+		Timeout:     20 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Outcome != DomainVerificationTimedOut || result.Domain == nil {
+		t.Errorf("unexpected result: %+v", result)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := client.Domains.WaitForVerification(ctx, "dom1", DomainVerificationOptions{PollOptions: PollOptions{Interval: time.Millisecond}}); err != context.Canceled {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
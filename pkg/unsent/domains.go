@@ -2,6 +2,15 @@ package unsent
 
 import "fmt"
 
+// Domain verification statuses reported by the API
+const (
+	DomainStatusNotStarted       = "NOT_STARTED"
+	DomainStatusPending          = "PENDING"
+	DomainStatusSuccess          = "SUCCESS"
+	DomainStatusFailed           = "FAILED"
+	DomainStatusTemporaryFailure = "TEMPORARY_FAILURE"
+)
+
 // DomainsClient handles domain-related API operations
 type DomainsClient struct {
 	client *Client
@@ -0,0 +1,199 @@
+package unsent
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EmailStatusScheduled is the status of an email waiting for its scheduled time
+const EmailStatusScheduled = "SCHEDULED"
+
+// scheduleLayouts are the local time formats accepted by ParseScheduleTime
+var scheduleLayouts = []string{
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+}
+
+// ParseScheduleTime parses a send time. Values with an offset (RFC 3339)
+// keep it; values without one, such as "2025-03-01 09:00", are read in loc,
+// so "9am in Berlin" stays 9am across daylight saving changes.
+func ParseScheduleTime(value string, loc *time.Location) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if loc == nil {
+		loc = time.UTC
+	}
+	for _, layout := range scheduleLayouts {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("cannot parse %q as a schedule time", value)
+}
+
+// RescheduleEmailBody is the typed body for moving a scheduled email
+type RescheduleEmailBody struct {
+	ScheduledAt time.Time
+}
+
+// toUpdateBody converts the body to the untyped map accepted by Emails.Update.
+// Times are sent in UTC.
+func (b RescheduleEmailBody) toUpdateBody() UpdateEmailJSONBody {
+	return UpdateEmailJSONBody{"scheduledAt": b.ScheduledAt.UTC().Format(time.RFC3339)}
+}
+
+// Reschedule moves a scheduled email to a new send time, which must be in the future
+func (e *EmailsClient) Reschedule(emailID string, at time.Time) (*EmailUpdateResponse, *APIError) {
+	if !at.After(time.Now()) {
+		return nil, &APIError{Code: "INVALID_SCHEDULE", Message: fmt.Sprintf("scheduled time %s is not in the future", at.Format(time.RFC3339))}
+	}
+	return e.Update(emailID, RescheduleEmailBody{ScheduledAt: at}.toUpdateBody())
+}
+
+// ScheduledEmailFilter narrows ListScheduled
+type ScheduledEmailFilter struct {
+	DomainIDs []string
+	// From and To bound the scheduled send time; zero values are open ended
+	From time.Time
+	To   time.Time
+	// CreatedAfter bounds the walk to emails created since then, defaults to
+	// 90 days ago. Emails scheduled earlier than that are not found.
+	CreatedAfter time.Time
+	// PageSize is the number of emails requested per page, defaults to 100
+	PageSize int
+}
+
+// ListScheduled walks the pages of List for emails created between
+// filter.CreatedAfter and filter.To, and returns those still scheduled,
+// ordered by send time. An email is created before its send time, so To
+// also bounds the creation date.
+func (e *EmailsClient) ListScheduled(filter ScheduledEmailFilter) ([]Email, *APIError) {
+	if filter.PageSize <= 0 {
+		filter.PageSize = 100
+	}
+	if filter.CreatedAfter.IsZero() {
+		filter.CreatedAfter = time.Now().AddDate(0, 0, -90)
+	}
+	start := filter.CreatedAfter.UTC()
+	var end *time.Time
+	if !filter.To.IsZero() {
+		to := filter.To.UTC()
+		end = &to
+	}
+	limit := strconv.Itoa(filter.PageSize)
+	var scheduled []Email
+	read := 0
+	for page := 1; ; page++ {
+		p := strconv.Itoa(page)
+		params := ListEmailsParams{Page: &p, Limit: &limit, StartDate: &start, EndDate: end}
+		params.SetDomainIDs(filter.DomainIDs...)
+		resp, apiErr := e.List(params)
+		if apiErr != nil {
+			return nil, apiErr
+		}
+		// read until an empty page, since the server may cap the page size
+		if len(resp.Data) == 0 {
+			break
+		}
+		read += len(resp.Data)
+		for _, email := range resp.Data {
+			if !strings.EqualFold(email.Status, EmailStatusScheduled) || email.ScheduledAt == nil {
+				continue
+			}
+			if !filter.From.IsZero() && email.ScheduledAt.Before(filter.From) {
+				continue
+			}
+			if !filter.To.IsZero() && email.ScheduledAt.After(filter.To) {
+				continue
+			}
+			scheduled = append(scheduled, email)
+		}
+		if resp.Count > 0 && read >= resp.Count {
+			break
+		}
+	}
+	sort.SliceStable(scheduled, func(i, j int) bool {
+		return scheduled[i].ScheduledAt.Before(*scheduled[j].ScheduledAt)
+	})
+	return scheduled, nil
+}
+
+// EmailReschedule pairs an email with its new send time
+type EmailReschedule struct {
+	EmailID     string
+	ScheduledAt time.Time
+}
+
+// ShiftSchedule moves every scheduled email by delta, e.g. when a launch slips by a day
+func ShiftSchedule(emails []Email, delta time.Duration) []EmailReschedule {
+	moves := make([]EmailReschedule, 0, len(emails))
+	for _, email := range emails {
+		if email.ScheduledAt == nil {
+			continue
+		}
+		moves = append(moves, EmailReschedule{EmailID: email.ID, ScheduledAt: email.ScheduledAt.Add(delta)})
+	}
+	return moves
+}
+
+// EmailBulkItemResult is the outcome for a single email of a bulk operation
+type EmailBulkItemResult struct {
+	EmailID string
+	Err     *APIError
+}
+
+// EmailBulkResult reports per-email outcomes in input order
+type EmailBulkResult struct {
+	Results []EmailBulkItemResult
+}
+
+// Succeeded returns the IDs of the emails that were updated
+func (r *EmailBulkResult) Succeeded() []string {
+	var ids []string
+	for _, item := range r.Results {
+		if item.Err == nil {
+			ids = append(ids, item.EmailID)
+		}
+	}
+	return ids
+}
+
+// Failed returns the items that could not be updated
+func (r *EmailBulkResult) Failed() []EmailBulkItemResult {
+	var failed []EmailBulkItemResult
+	for _, item := range r.Results {
+		if item.Err != nil {
+			failed = append(failed, item)
+		}
+	}
+	return failed
+}
+
+// BulkCancel cancels scheduled emails with up to concurrency requests in
+// flight (4 when zero). Failures do not stop the remaining cancellations.
+func (e *EmailsClient) BulkCancel(emailIDs []string, concurrency int) *EmailBulkResult {
+	result := &EmailBulkResult{Results: make([]EmailBulkItemResult, len(emailIDs))}
+	forEachConcurrent(len(emailIDs), concurrency, func(i int) {
+		_, err := e.Cancel(emailIDs[i])
+		result.Results[i] = EmailBulkItemResult{EmailID: emailIDs[i], Err: err}
+	})
+	return result
+}
+
+// BulkReschedule moves scheduled emails to new send times with up to
+// concurrency requests in flight (4 when zero)
+func (e *EmailsClient) BulkReschedule(moves []EmailReschedule, concurrency int) *EmailBulkResult {
+	result := &EmailBulkResult{Results: make([]EmailBulkItemResult, len(moves))}
+	forEachConcurrent(len(moves), concurrency, func(i int) {
+		_, err := e.Reschedule(moves[i].EmailID, moves[i].ScheduledAt)
+		result.Results[i] = EmailBulkItemResult{EmailID: moves[i].EmailID, Err: err}
+	})
+	return result
+}
@@ -0,0 +1,133 @@
+package unsent
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParseScheduleTime(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("time zone database not available")
+	}
+	got, err := ParseScheduleTime("2025-07-01 09:00", berlin)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := time.Date(2025, 7, 1, 7, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("expected %s, got %s", want, got.UTC())
+	}
+
+	got, err = ParseScheduleTime("2025-07-01T09:00:00-04:00", berlin)
+	if err != nil || !got.Equal(time.Date(2025, 7, 1, 13, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected explicit offset to win, got %s, %v", got, err)
+	}
+	if _, err := ParseScheduleTime("next tuesday", nil); err == nil {
+		t.Error("expected parse error")
+	}
+}
+
+func TestEmails_ListScheduled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if domains := query["domainId"]; len(domains) != 2 || domains[0] != "dom1" || domains[1] != "dom2" {
+			t.Errorf("expected domainId filter, got %v", domains)
+		}
+		if query.Get("startDate") != "2025-01-01T00:00:00Z" || query.Get("endDate") != "2025-03-31T00:00:00Z" {
+			t.Errorf("expected the walk bounded by creation date, got %s", r.URL.RawQuery)
+		}
+		switch query.Get("page") {
+		case "1":
+			w.Write([]byte(`{"data": [
+				{"id": "em1", "status": "SCHEDULED", "scheduledAt": "2025-03-02T09:00:00Z"},
+				{"id": "em2", "status": "SENT"}
+			]}`))
+		case "2":
+			w.Write([]byte(`{"data": [
+				{"id": "em3", "status": "SCHEDULED", "scheduledAt": "2025-03-01T09:00:00Z"},
+				{"id": "em4", "status": "SCHEDULED", "scheduledAt": "2025-04-01T09:00:00Z"}
+			]}`))
+		default:
+			w.Write([]byte(`{"data": []}`))
+		}
+	}))
+	defer server.Close()
+	client, _ := NewClient("key", WithBaseURL(server.URL))
+
+	emails, err := client.Emails.ListScheduled(ScheduledEmailFilter{
+		DomainIDs:    []string{"dom1", "dom2"},
+		To:           time.Date(2025, 3, 31, 0, 0, 0, 0, time.UTC),
+		CreatedAfter: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		PageSize:     3,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(emails) != 2 || emails[0].ID != "em3" || emails[1].ID != "em1" {
+		t.Errorf("expected em3, em1 ordered by send time, got %+v", emails)
+	}
+
+	moves := ShiftSchedule(emails, 24*time.Hour)
+	if len(moves) != 2 || !moves[0].ScheduledAt.Equal(time.Date(2025, 3, 2, 9, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected moves: %+v", moves)
+	}
+}
+
+func TestEmails_ListInvalidDomainFilter(t *testing.T) {
+	client, _ := NewClient("key", WithBaseURL("http://127.0.0.1:0"))
+	params := ListEmailsParams{DomainId: &struct {
+		union json.RawMessage
+	}{union: json.RawMessage(`42`)}}
+	if _, err := client.Emails.List(params); err == nil || err.Code != "BAD_REQUEST" {
+		t.Errorf("expected a BAD_REQUEST error, got %v", err)
+	}
+}
+
+func TestEmails_BulkCancelAndReschedule(t *testing.T) {
+	var mu sync.Mutex
+	scheduled := make(map[string]string)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := strings.Split(strings.TrimPrefix(r.URL.Path, "/v1/emails/"), "/")[0]
+		if id == "em_missing" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error": {"code": "NOT_FOUND", "message": "email not found"}}`))
+			return
+		}
+		if r.Method == "PATCH" {
+			var body map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&body)
+			mu.Lock()
+			scheduled[id], _ = body["scheduledAt"].(string)
+			mu.Unlock()
+		}
+		w.Write([]byte(`{"emailId": "` + id + `"}`))
+	}))
+	defer server.Close()
+	client, _ := NewClient("key", WithBaseURL(server.URL))
+
+	cancelled := client.Emails.BulkCancel([]string{"em1", "em_missing", "em2"}, 2)
+	if got := cancelled.Succeeded(); len(got) != 2 || got[0] != "em1" || got[1] != "em2" {
+		t.Errorf("unexpected successes: %v", got)
+	}
+	if failed := cancelled.Failed(); len(failed) != 1 || failed[0].EmailID != "em_missing" || failed[0].Err.Code != "NOT_FOUND" {
+		t.Errorf("unexpected failures: %+v", failed)
+	}
+
+	tokyo := time.FixedZone("JST", 9*60*60)
+	at := time.Now().Add(48 * time.Hour).In(tokyo).Truncate(time.Second)
+	rescheduled := client.Emails.BulkReschedule([]EmailReschedule{
+		{EmailID: "em3", ScheduledAt: at},
+		{EmailID: "em4", ScheduledAt: time.Now().Add(-time.Hour)},
+	}, 0)
+	if len(rescheduled.Succeeded()) != 1 || rescheduled.Failed()[0].Err.Code != "INVALID_SCHEDULE" {
+		t.Errorf("unexpected result: %+v", rescheduled.Results)
+	}
+	if scheduled["em3"] != at.UTC().Format(time.RFC3339) {
+		t.Errorf("expected UTC send time %s, got %s", at.UTC().Format(time.RFC3339), scheduled["em3"])
+	}
+}
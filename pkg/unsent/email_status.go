@@ -0,0 +1,218 @@
+package unsent
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// terminalEmailStatuses are the statuses after which an email's delivery
+// outcome no longer changes. Opens, clicks and complaints imply delivery.
+var terminalEmailStatuses = map[GetEmailEventsParamsStatus]bool{
+	GetEmailEventsParamsStatusDELIVERED:        true,
+	GetEmailEventsParamsStatusOPENED:           true,
+	GetEmailEventsParamsStatusCLICKED:          true,
+	GetEmailEventsParamsStatusCOMPLAINED:       true,
+	GetEmailEventsParamsStatusBOUNCED:          true,
+	GetEmailEventsParamsStatusFAILED:           true,
+	GetEmailEventsParamsStatusSUPPRESSED:       true,
+	GetEmailEventsParamsStatusREJECTED:         true,
+	GetEmailEventsParamsStatusCANCELLED:        true,
+	GetEmailEventsParamsStatusRENDERINGFAILURE: true,
+}
+
+// deliveredEmailStatuses are the terminal statuses of an email that reached the inbox
+var deliveredEmailStatuses = map[GetEmailEventsParamsStatus]bool{
+	GetEmailEventsParamsStatusDELIVERED:  true,
+	GetEmailEventsParamsStatusOPENED:     true,
+	GetEmailEventsParamsStatusCLICKED:    true,
+	GetEmailEventsParamsStatusCOMPLAINED: true,
+}
+
+// IsTerminalEmailStatus reports whether an email in this status has reached
+// its final delivery outcome. SCHEDULED, QUEUED, SENT and DELIVERY_DELAYED
+// are the only non-terminal statuses.
+func IsTerminalEmailStatus(status GetEmailEventsParamsStatus) bool {
+	return terminalEmailStatuses[GetEmailEventsParamsStatus(strings.ToUpper(string(status)))]
+}
+
+// EmailEvent is a single entry of an email's event timeline
+type EmailEvent struct {
+	ID        string
+	Status    GetEmailEventsParamsStatus
+	Timestamp time.Time
+	Data      map[string]interface{}
+}
+
+// parseEmailEvent reads the untyped event maps returned by Emails.GetEvents
+func parseEmailEvent(raw map[string]interface{}) EmailEvent {
+	event := EmailEvent{}
+	event.ID, _ = raw["id"].(string)
+	status, _ := raw["status"].(string)
+	if status == "" {
+		status, _ = raw["type"].(string)
+	}
+	event.Status = GetEmailEventsParamsStatus(strings.ToUpper(status))
+	for _, key := range []string{"createdAt", "timestamp"} {
+		if text, ok := raw[key].(string); ok {
+			if ts, err := time.Parse(time.RFC3339, text); err == nil {
+				event.Timestamp = ts
+				break
+			}
+		}
+	}
+	event.Data, _ = raw["data"].(map[string]interface{})
+	return event
+}
+
+// Timeline retrieves every event of an email, oldest first. Pages are read
+// until one comes back empty, since the server may cap the page size.
+func (e *EmailsClient) Timeline(emailID string) ([]EmailEvent, *APIError) {
+	limit := 100
+	var events []EmailEvent
+	for page := 1; ; page++ {
+		resp, apiErr := e.GetEvents(emailID, GetEmailEventsParams{Page: &page, Limit: &limit})
+		if apiErr != nil {
+			return nil, apiErr
+		}
+		if len(resp.Data) == 0 {
+			break
+		}
+		for _, raw := range resp.Data {
+			events = append(events, parseEmailEvent(raw))
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Timestamp.Before(events[j].Timestamp)
+	})
+	return events, nil
+}
+
+// EmailWaitOptions configures EmailsClient.WaitForStatusWithOptions
+type EmailWaitOptions struct {
+	PollOptions
+	// Targets are the statuses to wait for, defaults to any terminal status
+	Targets []GetEmailEventsParamsStatus
+	// MaxConsecutiveErrors stops the wait after this many failed polls in a row, defaults to 3
+	MaxConsecutiveErrors int
+}
+
+// EmailStatusResult describes where an email ended up
+type EmailStatusResult struct {
+	Email *Email
+	// Status is the target status that was reached, or the terminal status
+	// that made the targets unreachable
+	Status GetEmailEventsParamsStatus
+	// Reached is false when the email ended in a terminal status that is not a target
+	Reached  bool
+	Timeline []EmailEvent
+	Attempts int
+}
+
+// WaitForStatus polls an email with backoff until it reaches one of the
+// target statuses, or any terminal status when none are given. If the email
+// ends in a status from which no target can follow, e.g. BOUNCED while
+// waiting for DELIVERED, the result reports Reached as false rather than
+// waiting forever. Bound the wait with ctx.
+func (e *EmailsClient) WaitForStatus(ctx context.Context, emailID string, targets ...GetEmailEventsParamsStatus) (*EmailStatusResult, error) {
+	return e.WaitForStatusWithOptions(ctx, emailID, EmailWaitOptions{Targets: targets})
+}
+
+// WaitForStatusWithOptions is WaitForStatus with control over polling
+func (e *EmailsClient) WaitForStatusWithOptions(ctx context.Context, emailID string, opts EmailWaitOptions) (*EmailStatusResult, error) {
+	if opts.MaxConsecutiveErrors <= 0 {
+		opts.MaxConsecutiveErrors = 3
+	}
+	targets := make(map[GetEmailEventsParamsStatus]bool, len(opts.Targets))
+	for _, target := range opts.Targets {
+		targets[GetEmailEventsParamsStatus(strings.ToUpper(string(target)))] = true
+	}
+
+	result := &EmailStatusResult{}
+	wait := newBackoff(opts.PollOptions)
+	lastStatus := ""
+	failures := 0
+	for {
+		result.Attempts++
+		email, timeline, apiErr := e.poll(emailID)
+		if apiErr != nil {
+			failures++
+			if failures >= opts.MaxConsecutiveErrors {
+				return result, apiErr
+			}
+		} else {
+			failures = 0
+			result.Email, result.Timeline = email, timeline
+			if status, reached, done := emailWaitState(email, timeline, targets); done {
+				result.Status, result.Reached = status, reached
+				return result, nil
+			}
+			if email.Status != lastStatus {
+				lastStatus = email.Status
+				wait.reset()
+			}
+		}
+
+		if err := sleepContext(ctx, wait.next()); err != nil {
+			return result, err
+		}
+	}
+}
+
+func (e *EmailsClient) poll(emailID string) (*Email, []EmailEvent, *APIError) {
+	email, apiErr := e.Get(emailID)
+	if apiErr != nil {
+		return nil, nil, apiErr
+	}
+	timeline, apiErr := e.Timeline(emailID)
+	if apiErr != nil {
+		return nil, nil, apiErr
+	}
+	return email, timeline, nil
+}
+
+// emailWaitState checks the email status and its timeline against the
+// targets. After a failure status no target can be reached; after delivery
+// only the engagement statuses (opened, clicked, complained) can still follow.
+func emailWaitState(email *Email, timeline []EmailEvent, targets map[GetEmailEventsParamsStatus]bool) (GetEmailEventsParamsStatus, bool, bool) {
+	statuses := make([]GetEmailEventsParamsStatus, 0, len(timeline)+1)
+	for _, event := range timeline {
+		statuses = append(statuses, event.Status)
+	}
+	statuses = append(statuses, GetEmailEventsParamsStatus(strings.ToUpper(email.Status)))
+
+	for _, status := range statuses {
+		if targets[status] || (len(targets) == 0 && IsTerminalEmailStatus(status)) {
+			return status, true, true
+		}
+	}
+	waitingForEngagement := false
+	for target := range targets {
+		waitingForEngagement = waitingForEngagement || deliveredEmailStatuses[target]
+	}
+	for _, status := range statuses {
+		if IsTerminalEmailStatus(status) && !deliveredEmailStatuses[status] {
+			return status, false, true
+		}
+	}
+	for _, status := range statuses {
+		if deliveredEmailStatuses[status] && !waitingForEngagement {
+			return status, false, true
+		}
+	}
+	return "", false, false
+}
+
+// String summarises the result, e.g. "email em_123 reached DELIVERED after 3 polls"
+func (r *EmailStatusResult) String() string {
+	id := ""
+	if r.Email != nil {
+		id = r.Email.ID
+	}
+	if r.Reached {
+		return fmt.Sprintf("email %s reached %s after %d polls", id, r.Status, r.Attempts)
+	}
+	return fmt.Sprintf("email %s ended in %s after %d polls", id, r.Status, r.Attempts)
+}
@@ -0,0 +1,125 @@
+package unsent
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestIsTerminalEmailStatus(t *testing.T) {
+	for _, status := range []GetEmailEventsParamsStatus{"DELIVERED", "bounced", "SUPPRESSED", "REJECTED", "FAILED"} {
+		if !IsTerminalEmailStatus(status) {
+			t.Errorf("expected %s to be terminal", status)
+		}
+	}
+	for _, status := range []GetEmailEventsParamsStatus{"SCHEDULED", "QUEUED", "SENT", "DELIVERY_DELAYED"} {
+		if IsTerminalEmailStatus(status) {
+			t.Errorf("expected %s not to be terminal", status)
+		}
+	}
+}
+
+func newEmailStatusServer(t *testing.T, steps [][]string) (*httptest.Server, *int32) {
+	var polls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		i := int(atomic.LoadInt32(&polls))
+		if i >= len(steps) {
+			i = len(steps) - 1
+		}
+		statuses := steps[i]
+		switch r.URL.Path {
+		case "/v1/emails/em1":
+			w.Write([]byte(`{"id": "em1", "status": "` + statuses[len(statuses)-1] + `"}`))
+		case "/v1/emails/em1/events":
+			if r.URL.Query().Get("page") != "1" {
+				w.Write([]byte(`{"data": []}`))
+				return
+			}
+			var events []string
+			for j := len(statuses) - 1; j >= 0; j-- {
+				ts := time.Date(2024, 1, 1, 0, 0, j, 0, time.UTC).Format(time.RFC3339)
+				events = append(events, `{"id": "ev`+statuses[j]+`", "status": "`+statuses[j]+`", "createdAt": "`+ts+`"}`)
+			}
+			w.Write([]byte(`{"data": [` + strings.Join(events, ",") + `]}`))
+			atomic.AddInt32(&polls, 1)
+		default:
+			t.Errorf("unexpected request %s", r.URL.Path)
+		}
+	}))
+	return server, &polls
+}
+
+func TestEmails_WaitForStatusDelivered(t *testing.T) {
+	server, polls := newEmailStatusServer(t, [][]string{
+		{"QUEUED"},
+		{"QUEUED", "SENT"},
+		{"QUEUED", "SENT", "DELIVERED", "OPENED"},
+	})
+	defer server.Close()
+	client, _ := NewClient("key", WithBaseURL(server.URL))
+
+	result, err := client.Emails.WaitForStatusWithOptions(context.Background(), "em1", EmailWaitOptions{
+		PollOptions: PollOptions{Interval: time.Millisecond},
+		Targets:     []GetEmailEventsParamsStatus{GetEmailEventsParamsStatusDELIVERED},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.Reached || result.Status != GetEmailEventsParamsStatusDELIVERED || atomic.LoadInt32(polls) != 3 {
+		t.Errorf("unexpected result: %s", result)
+	}
+	if len(result.Timeline) != 4 || result.Timeline[0].Status != "QUEUED" || result.Timeline[3].Status != "OPENED" {
+		t.Errorf("expected timeline oldest first, got %+v", result.Timeline)
+	}
+}
+
+func TestEmails_WaitForStatusUnreachable(t *testing.T) {
+	server, _ := newEmailStatusServer(t, [][]string{{"QUEUED", "SENT", "BOUNCED"}})
+	defer server.Close()
+	client, _ := NewClient("key", WithBaseURL(server.URL))
+
+	result, err := client.Emails.WaitForStatus(context.Background(), "em1", GetEmailEventsParamsStatusDELIVERED)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Reached || result.Status != GetEmailEventsParamsStatusBOUNCED {
+		t.Errorf("expected BOUNCED to end the wait, got %s", result)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	pending, _ := newEmailStatusServer(t, [][]string{{"QUEUED", "DELIVERY_DELAYED"}})
+	defer pending.Close()
+	client, _ = NewClient("key", WithBaseURL(pending.URL))
+	if _, err := client.Emails.WaitForStatusWithOptions(ctx, "em1", EmailWaitOptions{PollOptions: PollOptions{Interval: time.Millisecond}}); err != context.DeadlineExceeded {
+		t.Errorf("expected context.DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestEmails_TimelineReadsEveryPage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the server caps the page size at 1, below the 100 requested
+		switch r.URL.Query().Get("page") {
+		case "1":
+			w.Write([]byte(`{"data": [{"id": "ev2", "status": "DELIVERED", "createdAt": "2024-01-01T00:00:02Z"}]}`))
+		case "2":
+			w.Write([]byte(`{"data": [{"id": "ev1", "status": "SENT", "createdAt": "2024-01-01T00:00:01Z"}]}`))
+		default:
+			w.Write([]byte(`{"data": []}`))
+		}
+	}))
+	defer server.Close()
+	client, _ := NewClient("key", WithBaseURL(server.URL))
+
+	timeline, err := client.Emails.Timeline("em1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(timeline) != 2 || timeline[0].Status != "SENT" {
+		t.Errorf("expected both pages oldest first, got %+v", timeline)
+	}
+}
@@ -1,6 +1,10 @@
 package unsent
 
-import "fmt"
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+)
 
 // EmailsClient handles email-related API operations
 type EmailsClient struct {
@@ -52,6 +56,13 @@ func (e *EmailsClient) List(params ListEmailsParams) (*ListEmailsResponse, *APIE
 	if params.EndDate != nil {
 		path += fmt.Sprintf("endDate=%s&", params.EndDate.Format("2006-01-02T15:04:05Z"))
 	}
+	domainIDs, err := params.DomainIDs()
+	if err != nil {
+		return nil, &APIError{Code: "BAD_REQUEST", Message: err.Error()}
+	}
+	for _, domainID := range domainIDs {
+		path += fmt.Sprintf("domainId=%s&", url.QueryEscape(domainID))
+	}
 	return Get[ListEmailsResponse](e.client, path)
 }
 
@@ -110,4 +121,36 @@ func (e *EmailsClient) GetEvents(emailID string, params GetEmailEventsParams) (*
 	return Get[GetEmailEventsResponse](e.client, path)
 }
 
+// SetDomainIDs filters List to emails sent from the given domains
+func (p *ListEmailsParams) SetDomainIDs(ids ...string) {
+	if len(ids) == 0 {
+		p.DomainId = nil
+		return
+	}
+	var raw []byte
+	if len(ids) == 1 {
+		raw, _ = json.Marshal(ids[0])
+	} else {
+		raw, _ = json.Marshal(ids)
+	}
+	p.DomainId = &struct {
+		union json.RawMessage
+	}{union: raw}
+}
 
+// DomainIDs returns the domain filter set with SetDomainIDs, or an error when
+// the filter is neither a string nor a list of strings
+func (p ListEmailsParams) DomainIDs() ([]string, error) {
+	if p.DomainId == nil {
+		return nil, nil
+	}
+	var one ListEmailsParamsDomainId0
+	if err := json.Unmarshal(p.DomainId.union, &one); err == nil {
+		return []string{one}, nil
+	}
+	var many ListEmailsParamsDomainId1
+	if err := json.Unmarshal(p.DomainId.union, &many); err != nil {
+		return nil, fmt.Errorf("invalid domainId filter: %w", err)
+	}
+	return many, nil
+}
@@ -0,0 +1,365 @@
+package unsent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// EventSource selects the endpoint an EventStream polls
+type EventSource string
+
+const (
+	// EventSourceEvents polls Events.List, filtered by StartDate
+	EventSourceEvents EventSource = "events"
+	// EventSourceActivity polls Activity.Get, which includes the email of every event
+	EventSourceActivity EventSource = "activity"
+)
+
+// StreamEvent is an event delivered by an EventStream
+type StreamEvent struct {
+	ID        string
+	EmailID   string
+	Type      string
+	Status    string
+	Timestamp time.Time
+	Data      map[string]interface{}
+	// Email is only set for events read from the activity feed
+	Email  *Email
+	Source EventSource
+}
+
+// EventCheckpoint is the position of an EventStream: the timestamp of the
+// newest event handled, and the timestamps of the events handled within the
+// stream's overlap window before it, by ID, so they are not redelivered when
+// the window is read again. Events older than Start, where the stream
+// began, are never delivered.
+type EventCheckpoint struct {
+	Start time.Time            `json:"start"`
+	Since time.Time            `json:"since"`
+	Seen  map[string]time.Time `json:"seen,omitempty"`
+}
+
+// from is the oldest timestamp a poll reads
+func (c *EventCheckpoint) from(overlap time.Duration) time.Time {
+	if from := c.Since.Add(-overlap); from.After(c.Start) {
+		return from
+	}
+	return c.Start
+}
+
+// CheckpointStore persists the position of an EventStream between restarts.
+// Load returns nil when nothing has been saved yet.
+type CheckpointStore interface {
+	Load(ctx context.Context) (*EventCheckpoint, error)
+	Save(ctx context.Context, checkpoint EventCheckpoint) error
+}
+
+// MemoryCheckpointStore keeps the checkpoint in memory
+type MemoryCheckpointStore struct {
+	mu         sync.Mutex
+	checkpoint *EventCheckpoint
+}
+
+func (m *MemoryCheckpointStore) Load(ctx context.Context) (*EventCheckpoint, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.checkpoint == nil {
+		return nil, nil
+	}
+	checkpoint := m.checkpoint.clone()
+	return &checkpoint, nil
+}
+
+func (m *MemoryCheckpointStore) Save(ctx context.Context, checkpoint EventCheckpoint) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	checkpoint = checkpoint.clone()
+	m.checkpoint = &checkpoint
+	return nil
+}
+
+// clone copies the checkpoint so the stream can keep updating Seen
+func (c EventCheckpoint) clone() EventCheckpoint {
+	seen := make(map[string]time.Time, len(c.Seen))
+	for id, at := range c.Seen {
+		seen[id] = at
+	}
+	c.Seen = seen
+	return c
+}
+
+// FileCheckpointStore keeps the checkpoint in a JSON file, replaced atomically on save
+type FileCheckpointStore struct {
+	Path string
+}
+
+func (f FileCheckpointStore) Load(ctx context.Context) (*EventCheckpoint, error) {
+	data, err := os.ReadFile(f.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var checkpoint EventCheckpoint
+	if err := json.Unmarshal(data, &checkpoint); err != nil {
+		return nil, err
+	}
+	return &checkpoint, nil
+}
+
+func (f FileCheckpointStore) Save(ctx context.Context, checkpoint EventCheckpoint) error {
+	data, err := json.Marshal(checkpoint)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(f.Path), filepath.Base(f.Path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), f.Path)
+}
+
+// EventStreamOptions configures an EventStream
+type EventStreamOptions struct {
+	PollOptions
+	// Source defaults to EventSourceEvents
+	Source EventSource
+	// Status only streams events with this status; ignored by the activity source
+	Status *GetEventsParamsStatus
+	// PageSize is the number of events requested per page, defaults to 100
+	PageSize int
+	// Store persists the checkpoint, defaults to an in-memory store
+	Store CheckpointStore
+	// Since is where a stream without a saved checkpoint starts, defaults to now
+	Since time.Time
+	// MaxConsecutiveErrors stops the stream after this many failed polls in a row, defaults to 5
+	MaxConsecutiveErrors int
+	// Overlap is how far before the checkpoint each poll reads again, so an
+	// event that shows up late with an older timestamp is still delivered.
+	// Defaults to one minute.
+	Overlap time.Duration
+}
+
+// EventStream polls the events API as an alternative to webhooks. Events are
+// delivered oldest first within a poll, deduplicated by ID, and the
+// checkpoint only moves past an event once it has been handled, so an event
+// may be redelivered after a crash. The checkpoint is a timestamp: an event
+// that arrives with a timestamp more than Overlap older than the newest
+// event handled is skipped.
+type EventStream struct {
+	client *Client
+	opts   EventStreamOptions
+}
+
+// NewEventStream creates a stream; call Run or Subscribe to start polling
+func NewEventStream(client *Client, opts EventStreamOptions) *EventStream {
+	if opts.Source == "" {
+		opts.Source = EventSourceEvents
+	}
+	if opts.PageSize <= 0 {
+		opts.PageSize = 100
+	}
+	if opts.Store == nil {
+		opts.Store = &MemoryCheckpointStore{}
+	}
+	if opts.MaxConsecutiveErrors <= 0 {
+		opts.MaxConsecutiveErrors = 5
+	}
+	if opts.Overlap <= 0 {
+		opts.Overlap = time.Minute
+	}
+	return &EventStream{client: client, opts: opts}
+}
+
+// Run polls until ctx is cancelled, polling fails MaxConsecutiveErrors times
+// in a row or handler returns an error. The event that failed is not
+// checkpointed and is delivered again on the next run.
+func (s *EventStream) Run(ctx context.Context, handler func(context.Context, StreamEvent) error) error {
+	checkpoint, err := s.opts.Store.Load(ctx)
+	if err != nil {
+		return err
+	}
+	if checkpoint == nil {
+		since := s.opts.Since
+		if since.IsZero() {
+			since = time.Now()
+		}
+		checkpoint = &EventCheckpoint{Start: since.UTC(), Since: since.UTC()}
+	}
+	if checkpoint.Seen == nil {
+		checkpoint.Seen = make(map[string]time.Time)
+	}
+
+	wait := newBackoff(s.opts.PollOptions)
+	failures := 0
+	for {
+		events, apiErr := s.poll(checkpoint.from(s.opts.Overlap))
+		if apiErr != nil {
+			failures++
+			if failures >= s.opts.MaxConsecutiveErrors {
+				return apiErr
+			}
+		} else {
+			failures = 0
+		}
+
+		delivered := 0
+		for _, event := range events {
+			if _, ok := checkpoint.Seen[event.ID]; ok || event.Timestamp.Before(checkpoint.from(s.opts.Overlap)) {
+				continue
+			}
+			if err := handler(ctx, event); err != nil {
+				return err
+			}
+			delivered++
+			checkpoint.Seen[event.ID] = event.Timestamp
+			if event.Timestamp.After(checkpoint.Since) {
+				checkpoint.Since = event.Timestamp
+				for id, at := range checkpoint.Seen {
+					if at.Before(checkpoint.Since.Add(-s.opts.Overlap)) {
+						delete(checkpoint.Seen, id)
+					}
+				}
+			}
+			if err := s.opts.Store.Save(ctx, *checkpoint); err != nil {
+				return err
+			}
+		}
+
+		if delivered > 0 {
+			wait.reset()
+		}
+		if err := sleepContext(ctx, wait.next()); err != nil {
+			return err
+		}
+	}
+}
+
+// StreamMessage is a single delivery from Subscribe. Call Ack once the event
+// has been handled; the stream waits for it before checkpointing and moving on.
+type StreamMessage struct {
+	Event StreamEvent
+	// Err is set on the final message when the stream stopped on an error
+	Err error
+	ack chan struct{}
+}
+
+// Ack marks the event as handled
+func (m StreamMessage) Ack() {
+	if m.ack != nil {
+		close(m.ack)
+	}
+}
+
+// Subscribe runs the stream in the background and delivers events on the
+// returned channel, which is closed when ctx is cancelled or the stream fails.
+// Cancel ctx when you stop reading so the background goroutine can exit.
+func (s *EventStream) Subscribe(ctx context.Context) <-chan StreamMessage {
+	messages := make(chan StreamMessage)
+	go func() {
+		defer close(messages)
+		err := s.Run(ctx, func(ctx context.Context, event StreamEvent) error {
+			ack := make(chan struct{})
+			select {
+			case messages <- StreamMessage{Event: event, ack: ack}:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			select {
+			case <-ack:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+		if err != nil && ctx.Err() == nil {
+			// a consumer that stopped reading must cancel ctx to release us
+			select {
+			case messages <- StreamMessage{Err: err}:
+			case <-ctx.Done():
+			}
+		}
+	}()
+	return messages
+}
+
+// poll fetches every event at or after since, oldest first with ties ordered by ID
+func (s *EventStream) poll(since time.Time) ([]StreamEvent, *APIError) {
+	var events []StreamEvent
+	limit := s.opts.PageSize
+	for page := 1; ; page++ {
+		var batch []StreamEvent
+		var meta *PaginationMeta
+		if s.opts.Source == EventSourceActivity {
+			resp, apiErr := s.client.Activity.Get(GetActivityParams{Page: &page, Limit: &limit})
+			if apiErr != nil {
+				return nil, apiErr
+			}
+			for i, activity := range resp.Data {
+				batch = append(batch, StreamEvent{
+					ID:        activity.ID,
+					EmailID:   activity.EmailID,
+					Type:      activity.Type,
+					Status:    activity.Type,
+					Timestamp: activity.CreatedAt,
+					Email:     &resp.Data[i].Email,
+					Source:    EventSourceActivity,
+				})
+			}
+			meta = resp.Meta
+		} else {
+			start := since
+			resp, apiErr := s.client.Events.List(GetEventsParams{Page: &page, Limit: &limit, Status: s.opts.Status, StartDate: &start})
+			if apiErr != nil {
+				return nil, apiErr
+			}
+			for _, event := range resp.Data {
+				batch = append(batch, StreamEvent{
+					ID:        event.ID,
+					EmailID:   event.EmailID,
+					Type:      event.Type,
+					Status:    event.Status,
+					Timestamp: event.Timestamp,
+					Data:      event.Data,
+					Source:    EventSourceEvents,
+				})
+			}
+			meta = resp.Meta
+		}
+		events = append(events, batch...)
+
+		// The activity feed has no date filter and is newest first, so stop
+		// paging once a page reaches events older than the checkpoint
+		reachedCheckpoint := false
+		for _, event := range batch {
+			if s.opts.Source == EventSourceActivity && event.Timestamp.Before(since) {
+				reachedCheckpoint = true
+			}
+		}
+		if reachedCheckpoint || len(batch) < limit || (meta != nil && page >= meta.TotalPages) {
+			break
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		if events[i].Timestamp.Equal(events[j].Timestamp) {
+			return events[i].ID < events[j].ID
+		}
+		return events[i].Timestamp.Before(events[j].Timestamp)
+	})
+	return events, nil
+}
@@ -0,0 +1,201 @@
+package unsent
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestEventStream_RunResumesFromCheckpoint(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/events" {
+			t.Errorf("unexpected request %s", r.URL.Path)
+		}
+		switch r.URL.Query().Get("startDate") {
+		// each poll reads the default one minute overlap again, but never
+		// before where the stream started
+		case "2024-01-01T00:00:00Z":
+		default:
+			t.Errorf("unexpected startDate %q", r.URL.Query().Get("startDate"))
+		}
+		// newest first, with evt2 and evt3 sharing a timestamp
+		w.Write([]byte(`{"data": [
+			{"id": "evt3", "emailId": "em3", "status": "BOUNCED", "timestamp": "2024-01-01T00:00:20Z"},
+			{"id": "evt2", "emailId": "em2", "status": "OPENED", "timestamp": "2024-01-01T00:00:20Z"},
+			{"id": "evt1", "emailId": "em1", "status": "DELIVERED", "timestamp": "2024-01-01T00:00:10Z"}
+		]}`))
+	}))
+	defer server.Close()
+	client, _ := NewClient("key", WithBaseURL(server.URL))
+
+	store := FileCheckpointStore{Path: filepath.Join(t.TempDir(), "checkpoint.json")}
+	opts := EventStreamOptions{
+		PollOptions: PollOptions{Interval: time.Millisecond},
+		Store:       store,
+		Since:       time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	// the handler fails on evt3, so it must be redelivered after a restart
+	var got []string
+	failOnce := errors.New("handler failed")
+	err := NewEventStream(client, opts).Run(context.Background(), func(ctx context.Context, event StreamEvent) error {
+		if event.ID == "evt3" {
+			return failOnce
+		}
+		got = append(got, event.ID)
+		return nil
+	})
+	if err != failOnce {
+		t.Fatalf("expected handler error, got %v", err)
+	}
+	if len(got) != 2 || got[0] != "evt1" || got[1] != "evt2" {
+		t.Errorf("expected evt1, evt2 in order, got %v", got)
+	}
+
+	checkpoint, _ := store.Load(context.Background())
+	if checkpoint == nil || !checkpoint.Since.Equal(time.Date(2024, 1, 1, 0, 0, 20, 0, time.UTC)) || len(checkpoint.Seen) != 2 {
+		t.Errorf("unexpected checkpoint: %+v", checkpoint)
+	}
+
+	got = nil
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	err = NewEventStream(client, opts).Run(ctx, func(ctx context.Context, event StreamEvent) error {
+		got = append(got, event.ID)
+		return nil
+	})
+	if err != context.DeadlineExceeded {
+		t.Errorf("expected context.DeadlineExceeded, got %v", err)
+	}
+	if len(got) != 1 || got[0] != "evt3" {
+		t.Errorf("expected only evt3 after resuming, got %v", got)
+	}
+}
+
+func TestEventStream_DeliversLateEventsWithinOverlap(t *testing.T) {
+	polls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		polls++
+		if polls == 1 {
+			w.Write([]byte(`{"data": [{"id": "evt2", "status": "OPENED", "timestamp": "2024-01-01T00:00:20Z"}]}`))
+			return
+		}
+		// evt1 shows up late, older than the checkpoint
+		w.Write([]byte(`{"data": [
+			{"id": "evt2", "status": "OPENED", "timestamp": "2024-01-01T00:00:20Z"},
+			{"id": "evt1", "status": "DELIVERED", "timestamp": "2024-01-01T00:00:15Z"},
+			{"id": "evt0", "status": "DELIVERED", "timestamp": "2024-01-01T00:00:05Z"}
+		]}`))
+	}))
+	defer server.Close()
+	client, _ := NewClient("key", WithBaseURL(server.URL))
+
+	store := &MemoryCheckpointStore{}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	var got []string
+	NewEventStream(client, EventStreamOptions{
+		PollOptions: PollOptions{Interval: time.Millisecond},
+		Store:       store,
+		Since:       time.Date(2024, 1, 1, 0, 0, 10, 0, time.UTC),
+		Overlap:     10 * time.Second,
+	}).Run(ctx, func(ctx context.Context, event StreamEvent) error {
+		got = append(got, event.ID)
+		return nil
+	})
+	// evt0 is older than the overlap window and stays skipped
+	if len(got) != 2 || got[0] != "evt2" || got[1] != "evt1" {
+		t.Errorf("expected evt2 then the late evt1 once, got %v", got)
+	}
+	checkpoint, _ := store.Load(context.Background())
+	if !checkpoint.Since.Equal(time.Date(2024, 1, 1, 0, 0, 20, 0, time.UTC)) || len(checkpoint.Seen) != 2 {
+		t.Errorf("unexpected checkpoint: %+v", checkpoint)
+	}
+}
+
+func TestEventStream_SubscribeActivity(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/activity" {
+			t.Errorf("unexpected request %s", r.URL.Path)
+		}
+		w.Write([]byte(`{"data": [
+			{"id": "act2", "emailId": "em1", "type": "OPENED", "createdAt": "2024-01-01T00:00:05Z", "email": {"id": "em1", "subject": "Hello"}},
+			{"id": "act1", "emailId": "em1", "type": "DELIVERED", "createdAt": "2024-01-01T00:00:01Z", "email": {"id": "em1", "subject": "Hello"}},
+			{"id": "act0", "emailId": "em0", "type": "DELIVERED", "createdAt": "2023-12-31T23:59:59Z"}
+		]}`))
+	}))
+	defer server.Close()
+	client, _ := NewClient("key", WithBaseURL(server.URL))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream := NewEventStream(client, EventStreamOptions{
+		Source:      EventSourceActivity,
+		PollOptions: PollOptions{Interval: time.Millisecond},
+		Since:       time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	})
+	messages := stream.Subscribe(ctx)
+
+	var got []StreamEvent
+	for msg := range messages {
+		if msg.Err != nil {
+			t.Fatalf("unexpected error: %v", msg.Err)
+		}
+		got = append(got, msg.Event)
+		msg.Ack()
+		if len(got) == 2 {
+			cancel()
+		}
+	}
+	if len(got) != 2 || got[0].ID != "act1" || got[1].ID != "act2" || got[1].Email == nil || got[1].Email.Subject != "Hello" {
+		t.Errorf("unexpected events: %+v", got)
+	}
+}
+
+func TestEventStream_StopsAfterConsecutiveErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"error": {"code": "INTERNAL_SERVER_ERROR", "message": "boom"}}`))
+	}))
+	defer server.Close()
+	client, _ := NewClient("key", WithBaseURL(server.URL))
+
+	stream := NewEventStream(client, EventStreamOptions{PollOptions: PollOptions{Interval: time.Millisecond}, MaxConsecutiveErrors: 2})
+	err := stream.Run(context.Background(), func(context.Context, StreamEvent) error { return nil })
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != "INTERNAL_SERVER_ERROR" {
+		t.Errorf("expected API error, got %v", err)
+	}
+}
+
+func TestEventStream_SubscribeReleasesAbandonedError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"error": {"code": "INTERNAL_SERVER_ERROR", "message": "boom"}}`))
+	}))
+	defer server.Close()
+	client, _ := NewClient("key", WithBaseURL(server.URL))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := NewEventStream(client, EventStreamOptions{PollOptions: PollOptions{Interval: time.Millisecond}, MaxConsecutiveErrors: 1})
+	messages := stream.Subscribe(ctx)
+
+	// nobody reads the final error; cancelling must still close the channel
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case _, ok := <-messages:
+			if !ok {
+				return
+			}
+		case <-timeout:
+			t.Fatal("expected the channel to close after cancelling")
+		}
+	}
+}
@@ -1,6 +1,9 @@
 package unsent
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"fmt"
+)
 
 // MakeSendEmailJSONBodyTo creates a SendEmailJSONBody_To from a string or []string
 func MakeSendEmailJSONBodyTo(v interface{}) SendEmailJSONBody_To {
@@ -43,3 +46,17 @@ func (t *SendBatchEmailsJSONBody_To) UnmarshalJSON(data []byte) error {
 	copy(t.union, data)
 	return nil
 }
+
+// Addresses returns the recipients set with MakeBatchEmailTo, or an error
+// when they are neither a string nor a list of strings
+func (t SendBatchEmailsJSONBody_To) Addresses() ([]string, error) {
+	var one SendBatchEmailsJSONBodyTo0
+	if err := json.Unmarshal(t.union, &one); err == nil {
+		return []string{one}, nil
+	}
+	var many SendBatchEmailsJSONBodyTo1
+	if err := json.Unmarshal(t.union, &many); err != nil {
+		return nil, fmt.Errorf("invalid recipients: %w", err)
+	}
+	return many, nil
+}
@@ -0,0 +1,219 @@
+package unsent
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// LogOptions configures the request logging enabled by WithLogger
+type LogOptions struct {
+	// RequestLevel is used for the line written before a request is sent
+	RequestLevel slog.Level
+	// ResponseLevel is used for successful responses
+	ResponseLevel slog.Level
+	// ErrorLevel is used for non-2xx responses and transport errors
+	ErrorLevel slog.Level
+	// LogBodies adds the request body to the request line. Message content
+	// (subject, html, text, attachments, variables) is always redacted.
+	LogBodies bool
+	// HashRecipients replaces recipient addresses with a short SHA-256 digest
+	// instead of "[redacted]", so one recipient can be followed across lines
+	HashRecipients bool
+}
+
+// DefaultLogOptions logs requests at debug, responses at info and errors at warn
+func DefaultLogOptions() LogOptions {
+	return LogOptions{
+		RequestLevel:  slog.LevelDebug,
+		ResponseLevel: slog.LevelInfo,
+		ErrorLevel:    slog.LevelWarn,
+	}
+}
+
+// WithLogger logs every API call with its method, path, status, duration,
+// attempt and request ID. The API key is masked and recipient addresses are
+// redacted. The client does not retry, so attempt is always 1.
+func WithLogger(logger *slog.Logger) ClientOption {
+	return func(c *Client) {
+		c.logger = logger
+		if c.logOptions == nil {
+			opts := DefaultLogOptions()
+			c.logOptions = &opts
+		}
+	}
+}
+
+// WithLogOptions overrides the levels and redaction used by WithLogger
+func WithLogOptions(opts LogOptions) ClientOption {
+	return func(c *Client) {
+		c.logOptions = &opts
+	}
+}
+
+// recipientKeys are the JSON fields holding recipient addresses
+var recipientKeys = map[string]bool{"to": true, "cc": true, "bcc": true, "replyto": true, "email": true}
+
+// contentKeys are the JSON fields holding message content
+var contentKeys = map[string]bool{"subject": true, "html": true, "text": true, "content": true, "attachments": true, "variables": true}
+
+// send performs req, logging it when a logger is configured
+func (c *Client) send(req *http.Request, body interface{}) (*http.Response, error) {
+	if c.logger == nil {
+		return c.HTTPClient.Do(req)
+	}
+	ctx := req.Context()
+	opts := c.logOptions
+	attrs := []slog.Attr{
+		slog.String("method", req.Method),
+		slog.String("path", c.redactURL(req.URL)),
+		slog.Int("attempt", 1),
+		slog.String("api_key", maskAPIKey(c.Key)),
+	}
+	requestAttrs := attrs
+	if opts.LogBodies && body != nil {
+		requestAttrs = append(attrs[:len(attrs):len(attrs)], slog.Any("body", c.redactBody(body)))
+	}
+	c.logger.LogAttrs(ctx, opts.RequestLevel, "unsent request", requestAttrs...)
+
+	start := time.Now()
+	resp, err := c.HTTPClient.Do(req)
+	attrs = append(attrs, slog.Duration("duration", time.Since(start)))
+	if err != nil {
+		attrs = append(attrs, slog.String("error", c.redactText(err.Error())))
+		c.logger.LogAttrs(ctx, opts.ErrorLevel, "unsent request failed", attrs...)
+		return nil, err
+	}
+
+	attrs = append(attrs, slog.Int("status", resp.StatusCode))
+	if id := resp.Header.Get("X-Request-Id"); id != "" {
+		attrs = append(attrs, slog.String("request_id", id))
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		c.logger.LogAttrs(ctx, opts.ResponseLevel, "unsent response", attrs...)
+		return resp, nil
+	}
+
+	// read the error body to log its code, then hand it back to the caller
+	respBody, readErr := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+	if readErr == nil {
+		if code := errorCode(respBody); code != "" {
+			attrs = append(attrs, slog.String("error_code", code))
+		}
+	}
+	c.logger.LogAttrs(ctx, opts.ErrorLevel, "unsent response", attrs...)
+	return resp, nil
+}
+
+// errorCode extracts the code of a flat or nested API error body
+func errorCode(body []byte) string {
+	var flat APIError
+	if json.Unmarshal(body, &flat) == nil && flat.Code != "" {
+		return flat.Code
+	}
+	var nested struct {
+		Error APIError `json:"error"`
+	}
+	json.Unmarshal(body, &nested)
+	return nested.Error.Code
+}
+
+// maskAPIKey keeps the key's prefix and last four characters
+func maskAPIKey(key string) string {
+	if len(key) <= 8 {
+		return "***"
+	}
+	prefix := ""
+	if i := strings.Index(key, "_"); i >= 0 && i < 6 {
+		prefix = key[:i+1]
+	}
+	return prefix + "***" + key[len(key)-4:]
+}
+
+// redactText masks the API key wherever it appears in s
+func (c *Client) redactText(s string) string {
+	if c.Key == "" {
+		return s
+	}
+	return strings.ReplaceAll(s, c.Key, maskAPIKey(c.Key))
+}
+
+// redactAddress hides a recipient address, or digests it with HashRecipients
+func (c *Client) redactAddress(address string) string {
+	if !c.logOptions.HashRecipients {
+		return "[redacted]"
+	}
+	sum := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(address))))
+	return "sha256:" + hex.EncodeToString(sum[:6])
+}
+
+// redactURL returns the path and query with addresses, such as the one in
+// /suppressions/email/{email}, redacted
+func (c *Client) redactURL(u *url.URL) string {
+	segments := strings.Split(u.Path, "/")
+	for i, segment := range segments {
+		if unescaped, err := url.PathUnescape(segment); err == nil && strings.Contains(unescaped, "@") {
+			segments[i] = c.redactAddress(unescaped)
+		}
+	}
+	path := strings.Join(segments, "/")
+	if u.RawQuery == "" {
+		return path
+	}
+	query := u.Query()
+	for key, values := range query {
+		for i, value := range values {
+			if strings.Contains(value, "@") {
+				values[i] = c.redactAddress(value)
+			}
+		}
+		query[key] = values
+	}
+	return path + "?" + query.Encode()
+}
+
+// redactBody converts a request body to plain JSON values with message
+// content and recipient addresses redacted
+func (c *Client) redactBody(body interface{}) interface{} {
+	raw, err := json.Marshal(body)
+	if err != nil {
+		return nil
+	}
+	var value interface{}
+	if json.Unmarshal(raw, &value) != nil {
+		return nil
+	}
+	return c.redactValue("", value)
+}
+
+func (c *Client) redactValue(key string, value interface{}) interface{} {
+	key = strings.ToLower(key)
+	if contentKeys[key] && value != nil {
+		return "[redacted]"
+	}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for k, item := range v {
+			v[k] = c.redactValue(k, item)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = c.redactValue(key, item)
+		}
+	case string:
+		if recipientKeys[key] || key != "from" && strings.Contains(v, "@") {
+			return c.redactAddress(v)
+		}
+		return c.redactText(v)
+	}
+	return value
+}
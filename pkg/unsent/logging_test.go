@@ -0,0 +1,105 @@
+package unsent
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func decodeLogLines(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	var lines []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var entry map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("invalid log line %q: %v", line, err)
+		}
+		lines = append(lines, entry)
+	}
+	return lines
+}
+
+func TestWithLogger_RedactsRequests(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-Id", "req_123")
+		w.Write([]byte(`{"emailId": "em1"}`))
+	}))
+	defer server.Close()
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	opts := DefaultLogOptions()
+	opts.LogBodies = true
+	client, _ := NewClient("un_secretkey1234", WithBaseURL(server.URL), WithLogger(logger), WithLogOptions(opts))
+
+	subject, html := "Your invoice", "<p>Balance: $42</p>"
+	if _, err := client.Emails.Send(SendEmailJSONBody{
+		To:      MakeSendEmailJSONBodyTo([]string{"jane@example.com"}),
+		From:    "billing@company.com",
+		Subject: &subject,
+		Html:    &html,
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	output := buf.String()
+	for _, secret := range []string{"un_secretkey1234", "jane@example.com", "Balance", "Your invoice"} {
+		if strings.Contains(output, secret) {
+			t.Errorf("expected %q to be redacted, got %s", secret, output)
+		}
+	}
+
+	lines := decodeLogLines(t, &buf)
+	if len(lines) != 2 {
+		t.Fatalf("expected request and response lines, got %d", len(lines))
+	}
+	request, response := lines[0], lines[1]
+	if request["level"] != "DEBUG" || request["method"] != "POST" || request["path"] != "/v1/emails" || request["api_key"] != "un_***1234" {
+		t.Errorf("unexpected request line: %v", request)
+	}
+	body, _ := request["body"].(map[string]interface{})
+	if body["from"] != "billing@company.com" || body["to"].([]interface{})[0] != "[redacted]" || body["html"] != "[redacted]" {
+		t.Errorf("unexpected body: %v", body)
+	}
+	if response["level"] != "INFO" || response["status"] != float64(200) || response["request_id"] != "req_123" || response["attempt"] != float64(1) {
+		t.Errorf("unexpected response line: %v", response)
+	}
+	if _, ok := response["duration"]; !ok {
+		t.Errorf("expected duration, got %v", response)
+	}
+}
+
+func TestWithLogger_ErrorsAndHashing(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error": {"code": "NOT_FOUND", "message": "not suppressed"}}`))
+	}))
+	defer server.Close()
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	opts := DefaultLogOptions()
+	opts.HashRecipients = true
+	client, _ := NewClient("un_secretkey1234", WithBaseURL(server.URL), WithLogger(logger), WithLogOptions(opts))
+
+	_, apiErr := client.Suppressions.Delete("Jane@Example.com")
+	if apiErr == nil || apiErr.Code != "NOT_FOUND" {
+		t.Fatalf("expected the error body to reach the caller, got %v", apiErr)
+	}
+	client.Suppressions.Delete("jane@example.com")
+
+	lines := decodeLogLines(t, &buf)
+	if len(lines) != 2 {
+		t.Fatalf("expected only the warn lines, got %d", len(lines))
+	}
+	if lines[0]["level"] != "WARN" || lines[0]["error_code"] != "NOT_FOUND" || lines[0]["status"] != float64(404) {
+		t.Errorf("unexpected error line: %v", lines[0])
+	}
+	path, _ := lines[0]["path"].(string)
+	if !strings.HasPrefix(path, "/v1/suppressions/email/sha256:") || strings.Contains(path, "example") || path != lines[1]["path"] {
+		t.Errorf("expected the same hashed address in both lines, got %v and %v", path, lines[1]["path"])
+	}
+}
@@ -16,6 +16,7 @@ type Email struct {
 	HTML        string                   `json:"html,omitempty"`
 	Text        string                   `json:"text,omitempty"`
 	Status      string                   `json:"status"`
+	DomainID    string                   `json:"domainId,omitempty"`
 	Attachments []map[string]interface{} `json:"attachments,omitempty"`
 	ScheduledAt *time.Time               `json:"scheduledAt,omitempty"`
 	SentAt      *time.Time               `json:"sentAt,omitempty"`
@@ -40,13 +41,14 @@ type EmailBatchResponse struct {
 
 // Contact represents a contact
 type Contact struct {
-	ID        string                 `json:"id"`
-	Email     string                 `json:"email"`
-	FirstName string                 `json:"firstName,omitempty"`
-	LastName  string                 `json:"lastName,omitempty"`
-	Metadata  map[string]interface{} `json:"metadata,omitempty"`
-	CreatedAt time.Time              `json:"createdAt"`
-	UpdatedAt time.Time              `json:"updatedAt"`
+	ID         string                 `json:"id"`
+	Email      string                 `json:"email"`
+	FirstName  string                 `json:"firstName,omitempty"`
+	LastName   string                 `json:"lastName,omitempty"`
+	Subscribed *bool                  `json:"subscribed,omitempty"`
+	Metadata   map[string]interface{} `json:"metadata,omitempty"`
+	CreatedAt  time.Time              `json:"createdAt"`
+	UpdatedAt  time.Time              `json:"updatedAt"`
 }
 
 // ContactCreateResponse represents the response from creating a contact
@@ -117,19 +119,31 @@ type CampaignActionResponse struct {
 
 // Domain represents a domain
 type Domain struct {
-	ID        string    `json:"id"`
-	Domain    string    `json:"name"`
-	Status    string    `json:"status"`
-	CreatedAt time.Time `json:"createdAt"`
-	UpdatedAt time.Time `json:"updatedAt"`
+	ID         string            `json:"id"`
+	Domain     string            `json:"name"`
+	Status     string            `json:"status"`
+	DNSRecords []DomainDNSRecord `json:"dnsRecords,omitempty"`
+	CreatedAt  time.Time         `json:"createdAt"`
+	UpdatedAt  time.Time         `json:"updatedAt"`
 }
 
 // DomainCreateResponse represents the response from creating a domain
 type DomainCreateResponse struct {
-	ID        string    `json:"id"`
-	Domain    string    `json:"domain"`
-	Status    string    `json:"status"`
-	CreatedAt time.Time `json:"createdAt"`
+	ID         string            `json:"id"`
+	Domain     string            `json:"domain"`
+	Status     string            `json:"status"`
+	DNSRecords []DomainDNSRecord `json:"dnsRecords,omitempty"`
+	CreatedAt  time.Time         `json:"createdAt"`
+}
+
+// DomainDNSRecord is a DNS record that must be published for a domain to verify
+type DomainDNSRecord struct {
+	Type     string `json:"type"`
+	Name     string `json:"name"`
+	Value    string `json:"value"`
+	TTL      string `json:"ttl,omitempty"`
+	Priority *int   `json:"priority,omitempty"`
+	Status   string `json:"status,omitempty"`
 }
 
 // DomainVerifyResponse represents the response from verifying a domain
@@ -274,11 +288,14 @@ type TemplateDeleteResponse struct {
 
 // Webhook
 type Webhook struct {
-	ID        string    `json:"id"`
-	Url       string    `json:"url"`
-	Events    []string  `json:"events"`
-	CreatedAt time.Time `json:"createdAt"`
-	UpdatedAt time.Time `json:"updatedAt"`
+	ID          string    `json:"id"`
+	Url         string    `json:"url"`
+	Events      []string  `json:"events"`
+	EventTypes  []string  `json:"eventTypes,omitempty"`
+	Active      *bool     `json:"active,omitempty"`
+	Description *string   `json:"description,omitempty"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
 }
 
 type WebhookCreateRequest struct {
@@ -292,17 +309,42 @@ type WebhookUpdateRequest struct {
 }
 
 type WebhookCreateResponse struct {
-	ID string `json:"id"`
+	ID     string `json:"id"`
+	Secret string `json:"secret,omitempty"`
 }
 
 type WebhookUpdateResponse struct {
-	Success bool `json:"success"`
+	Success bool   `json:"success"`
+	Secret  string `json:"secret,omitempty"`
 }
 
 type WebhookDeleteResponse struct {
 	Success bool `json:"success"`
 }
 
+// WebhookDelivery is a single attempt to deliver an event to a webhook
+type WebhookDelivery struct {
+	ID             string   `json:"id"`
+	Type           string   `json:"type"`
+	CreatedAt      string   `json:"createdAt"`
+	UpdatedAt      string   `json:"updatedAt"`
+	TeamID         string   `json:"teamId"`
+	Status         string   `json:"status"`
+	WebhookID      string   `json:"webhookId"`
+	Payload        string   `json:"payload"`
+	Attempt        float32  `json:"attempt"`
+	NextAttemptAt  *string  `json:"nextAttemptAt,omitempty"`
+	LastError      *string  `json:"lastError,omitempty"`
+	ResponseStatus *float32 `json:"responseStatus,omitempty"`
+	ResponseTimeMs *float32 `json:"responseTimeMs,omitempty"`
+	ResponseText   *string  `json:"responseText,omitempty"`
+}
+
+type WebhookDeliveriesResponse struct {
+	Data []WebhookDelivery `json:"data"`
+	Meta *PaginationMeta   `json:"meta,omitempty"`
+}
+
 // Pagination support
 type PaginationMeta struct {
 	Total      int `json:"total"`
@@ -327,6 +369,7 @@ type CampaignDetail struct {
 	BatchWindowMinutes int       `json:"batchWindowMinutes"`
 	Total              int       `json:"total"`
 	Sent               int       `json:"sent"`
+	Failed             int       `json:"failed"`
 	Delivered          int       `json:"delivered"`
 	Opened             int       `json:"opened"`
 	Clicked            int       `json:"clicked"`
@@ -0,0 +1,69 @@
+package unsent
+
+import (
+	"context"
+	"time"
+)
+
+// PollOptions configures helpers that poll the API until a condition is met.
+// The delay between polls starts at Interval and grows by Multiplier up to
+// MaxInterval while nothing changes.
+type PollOptions struct {
+	Interval    time.Duration
+	MaxInterval time.Duration
+	Multiplier  float64
+}
+
+func (o PollOptions) withDefaults() PollOptions {
+	if o.Interval <= 0 {
+		o.Interval = 2 * time.Second
+	}
+	if o.MaxInterval < o.Interval {
+		o.MaxInterval = 30 * time.Second
+		if o.MaxInterval < o.Interval {
+			o.MaxInterval = o.Interval
+		}
+	}
+	if o.Multiplier < 1 {
+		o.Multiplier = 1.5
+	}
+	return o
+}
+
+// backoff tracks the delay before the next poll
+type backoff struct {
+	opts    PollOptions
+	current time.Duration
+}
+
+func newBackoff(opts PollOptions) *backoff {
+	opts = opts.withDefaults()
+	return &backoff{opts: opts, current: opts.Interval}
+}
+
+// next returns the current delay and grows the following one
+func (b *backoff) next() time.Duration {
+	d := b.current
+	b.current = time.Duration(float64(b.current) * b.opts.Multiplier)
+	if b.current > b.opts.MaxInterval {
+		b.current = b.opts.MaxInterval
+	}
+	return d
+}
+
+// reset returns the delay to the initial interval
+func (b *backoff) reset() {
+	b.current = b.opts.Interval
+}
+
+// sleepContext waits for d or until ctx is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,123 @@
+package unsent
+
+import "strings"
+
+// Route identifies an API endpoint independently of the IDs in its path,
+// for use as a low-cardinality label in logs, traces and metrics
+type Route struct {
+	Method string
+	// Template is the path with IDs replaced by placeholders, e.g. "/emails/{emailId}"
+	Template string
+	// Operation is a stable name such as "unsent.emails.create"
+	Operation string
+}
+
+// routes lists every endpoint the client calls. Literal segments must come
+// before placeholders on the same path, e.g. /emails/batch before /emails/{emailId}.
+var routes = []Route{
+	{"GET", "/activity", "unsent.activity.get"},
+	{"GET", "/analytics", "unsent.analytics.get"},
+	{"GET", "/analytics/time-series", "unsent.analytics.time_series"},
+	{"GET", "/analytics/reputation", "unsent.analytics.reputation"},
+	{"GET", "/api-keys", "unsent.api_keys.list"},
+	{"POST", "/api-keys", "unsent.api_keys.create"},
+	{"DELETE", "/api-keys/{apiKeyId}", "unsent.api_keys.delete"},
+	{"GET", "/campaigns", "unsent.campaigns.list"},
+	{"POST", "/campaigns", "unsent.campaigns.create"},
+	{"GET", "/campaigns/{campaignId}", "unsent.campaigns.get"},
+	{"PATCH", "/campaigns/{campaignId}", "unsent.campaigns.update"},
+	{"DELETE", "/campaigns/{campaignId}", "unsent.campaigns.delete"},
+	{"POST", "/campaigns/{campaignId}/schedule", "unsent.campaigns.schedule"},
+	{"POST", "/campaigns/{campaignId}/pause", "unsent.campaigns.pause"},
+	{"POST", "/campaigns/{campaignId}/resume", "unsent.campaigns.resume"},
+	{"POST", "/campaigns/{campaignId}/cancel", "unsent.campaigns.cancel"},
+	{"POST", "/campaigns/{campaignId}/duplicate", "unsent.campaigns.duplicate"},
+	{"POST", "/campaigns/{campaignId}/test", "unsent.campaigns.test_send"},
+	{"GET", "/contactBooks", "unsent.contact_books.list"},
+	{"POST", "/contactBooks", "unsent.contact_books.create"},
+	{"GET", "/contactBooks/{contactBookId}", "unsent.contact_books.get"},
+	{"PATCH", "/contactBooks/{contactBookId}", "unsent.contact_books.update"},
+	{"DELETE", "/contactBooks/{contactBookId}", "unsent.contact_books.delete"},
+	{"GET", "/contactBooks/{contactBookId}/contacts", "unsent.contacts.list"},
+	{"POST", "/contactBooks/{contactBookId}/contacts", "unsent.contacts.create"},
+	{"GET", "/contactBooks/{contactBookId}/contacts/{contactId}", "unsent.contacts.get"},
+	{"PATCH", "/contactBooks/{contactBookId}/contacts/{contactId}", "unsent.contacts.update"},
+	{"PUT", "/contactBooks/{contactBookId}/contacts/{contactId}", "unsent.contacts.upsert"},
+	{"DELETE", "/contactBooks/{contactBookId}/contacts/{contactId}", "unsent.contacts.delete"},
+	{"GET", "/domains", "unsent.domains.list"},
+	{"POST", "/domains", "unsent.domains.create"},
+	{"GET", "/domains/{domainId}", "unsent.domains.get"},
+	{"DELETE", "/domains/{domainId}", "unsent.domains.delete"},
+	{"PUT", "/domains/{domainId}/verify", "unsent.domains.verify"},
+	{"GET", "/domains/{domainId}/analytics", "unsent.domains.analytics"},
+	{"GET", "/domains/{domainId}/stats", "unsent.domains.stats"},
+	{"GET", "/emails", "unsent.emails.list"},
+	{"POST", "/emails", "unsent.emails.create"},
+	{"POST", "/emails/batch", "unsent.emails.batch"},
+	{"GET", "/emails/bounces", "unsent.emails.bounces"},
+	{"GET", "/emails/complaints", "unsent.emails.complaints"},
+	{"GET", "/emails/unsubscribes", "unsent.emails.unsubscribes"},
+	{"GET", "/emails/{emailId}", "unsent.emails.get"},
+	{"PATCH", "/emails/{emailId}", "unsent.emails.update"},
+	{"POST", "/emails/{emailId}/cancel", "unsent.emails.cancel"},
+	{"GET", "/emails/{emailId}/events", "unsent.emails.events"},
+	{"GET", "/events", "unsent.events.list"},
+	{"GET", "/health", "unsent.system.health"},
+	{"GET", "/metrics", "unsent.metrics.get"},
+	{"GET", "/settings", "unsent.settings.get"},
+	{"GET", "/stats", "unsent.stats.get"},
+	{"GET", "/suppressions", "unsent.suppressions.list"},
+	{"POST", "/suppressions", "unsent.suppressions.add"},
+	{"DELETE", "/suppressions/email/{email}", "unsent.suppressions.delete"},
+	{"GET", "/team", "unsent.teams.get"},
+	{"GET", "/teams", "unsent.teams.list"},
+	{"GET", "/templates", "unsent.templates.list"},
+	{"POST", "/templates", "unsent.templates.create"},
+	{"GET", "/templates/{templateId}", "unsent.templates.get"},
+	{"PATCH", "/templates/{templateId}", "unsent.templates.update"},
+	{"DELETE", "/templates/{templateId}", "unsent.templates.delete"},
+	{"GET", "/version", "unsent.system.version"},
+	{"GET", "/webhooks", "unsent.webhooks.list"},
+	{"POST", "/webhooks", "unsent.webhooks.create"},
+	{"GET", "/webhooks/{webhookId}", "unsent.webhooks.get"},
+	{"PATCH", "/webhooks/{webhookId}", "unsent.webhooks.update"},
+	{"DELETE", "/webhooks/{webhookId}", "unsent.webhooks.delete"},
+	{"POST", "/webhooks/{webhookId}/test", "unsent.webhooks.test"},
+	{"GET", "/webhooks/{webhookId}/deliveries", "unsent.webhooks.deliveries"},
+	{"GET", "/webhooks/{webhookId}/deliveries/{deliveryId}", "unsent.webhooks.delivery"},
+	{"POST", "/webhooks/{webhookId}/deliveries/{deliveryId}/redeliver", "unsent.webhooks.redeliver"},
+}
+
+// MatchRoute finds the endpoint for a request path, with or without the /v1
+// prefix and query string. Unknown paths get the operation "unsent.request"
+// and an empty template.
+func MatchRoute(method, path string) Route {
+	if i := strings.IndexByte(path, '?'); i >= 0 {
+		path = path[:i]
+	}
+	path = strings.TrimPrefix(path, "/v1")
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for _, route := range routes {
+		if route.Method == method && matchSegments(route.Template, segments) {
+			return route
+		}
+	}
+	return Route{Method: method, Operation: "unsent.request"}
+}
+
+func matchSegments(template string, segments []string) bool {
+	parts := strings.Split(strings.Trim(template, "/"), "/")
+	if len(parts) != len(segments) {
+		return false
+	}
+	for i, part := range parts {
+		if strings.HasPrefix(part, "{") {
+			if segments[i] == "" {
+				return false
+			}
+		} else if part != segments[i] {
+			return false
+		}
+	}
+	return true
+}
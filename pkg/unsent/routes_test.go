@@ -0,0 +1,68 @@
+package unsent
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestMatchRoute(t *testing.T) {
+	cases := []struct {
+		method, path        string
+		template, operation string
+	}{
+		{"POST", "/v1/emails", "/emails", "unsent.emails.create"},
+		{"POST", "/v1/emails/batch", "/emails/batch", "unsent.emails.batch"},
+		{"GET", "/v1/emails/bounces?page=1", "/emails/bounces", "unsent.emails.bounces"},
+		{"GET", "/v1/emails/em_123", "/emails/{emailId}", "unsent.emails.get"},
+		{"GET", "/contactBooks/cb1/contacts?limit=10", "/contactBooks/{contactBookId}/contacts", "unsent.contacts.list"},
+		{"PUT", "/v1/contactBooks/cb1/contacts/c1", "/contactBooks/{contactBookId}/contacts/{contactId}", "unsent.contacts.upsert"},
+		{"POST", "/v1/webhooks/wh1/deliveries/d1/redeliver", "/webhooks/{webhookId}/deliveries/{deliveryId}/redeliver", "unsent.webhooks.redeliver"},
+		{"DELETE", "/v1/suppressions/email/jane@example.com", "/suppressions/email/{email}", "unsent.suppressions.delete"},
+		{"GET", "/v1/unknown/thing", "", "unsent.request"},
+		{"DELETE", "/v1/emails", "", "unsent.request"},
+	}
+	for _, c := range cases {
+		route := MatchRoute(c.method, c.path)
+		if route.Template != c.template || route.Operation != c.operation || route.Method != c.method {
+			t.Errorf("%s %s: expected %s %s, got %+v", c.method, c.path, c.template, c.operation, route)
+		}
+	}
+}
+
+func TestMatchRoute_CoversClientPaths(t *testing.T) {
+	// every request the client makes should map to a named route
+	seen := make(map[string]bool)
+	server := newRouteRecorder(seen)
+	defer server.Close()
+	client, _ := NewClient("key", WithBaseURL(server.URL))
+
+	client.Emails.Send(SendEmailJSONBody{})
+	client.Emails.GetEvents("em1", GetEmailEventsParams{})
+	client.Contacts.List("cb1", GetContactsParams{})
+	client.Domains.Verify("d1")
+	client.Analytics.GetReputation(GetReputationParams{})
+	client.Webhooks.ListDeliveries("wh1", ListWebhookDeliveriesParams{})
+	client.Suppressions.Delete("jane@example.com")
+
+	if len(seen) != 7 {
+		t.Errorf("expected 7 distinct requests, got %v", seen)
+	}
+	for key := range seen {
+		if key == "unsent.request" {
+			t.Errorf("found a request without a route")
+		}
+	}
+}
+
+func newRouteRecorder(seen map[string]bool) *httptest.Server {
+	var mu sync.Mutex
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := MatchRoute(r.Method, r.URL.Path)
+		mu.Lock()
+		seen[route.Operation] = true
+		mu.Unlock()
+		w.Write([]byte(`{}`))
+	}))
+}
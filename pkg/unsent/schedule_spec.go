@@ -0,0 +1,315 @@
+package unsent
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type scheduleKind int
+
+const (
+	scheduleNone scheduleKind = iota
+	scheduleTime
+	scheduleNatural
+	scheduleRecipientLocal
+)
+
+// ScheduleSpec is a send time that can be converted for every endpoint that
+// accepts one: an absolute time, a natural-language phrase such as
+// "tomorrow 9am", or a wall-clock time in each recipient's own time zone.
+// The zero value means "send now".
+type ScheduleSpec struct {
+	kind   scheduleKind
+	at     time.Time
+	phrase string
+	hour   int
+	minute int
+	date   string
+	zone   *time.Location
+	now    func() time.Time
+}
+
+// ScheduleAt schedules for an absolute time
+func ScheduleAt(t time.Time) ScheduleSpec {
+	return ScheduleSpec{kind: scheduleTime, at: t}
+}
+
+// ScheduleNatural schedules with a phrase such as "now", "in 2 hours",
+// "tomorrow 9am", "next monday 10:30" or an ISO 8601 time. Phrases are resolved in
+// zone (UTC when nil); a phrase without a time of day means 9am.
+func ScheduleNatural(phrase string, zone *time.Location) ScheduleSpec {
+	return ScheduleSpec{kind: scheduleNatural, phrase: phrase, zone: zone}
+}
+
+// ScheduleRecipientLocal sends at a wall-clock time such as "09:00" or "9am"
+// in each recipient's time zone, at the next occurrence of that time.
+// Recipients without a known zone use fallback (UTC when nil).
+func ScheduleRecipientLocal(clock string, fallback *time.Location) (ScheduleSpec, error) {
+	hour, minute, ok := parseClock(strings.ToLower(strings.TrimSpace(clock)))
+	if !ok {
+		return ScheduleSpec{}, fmt.Errorf("cannot parse %q as a time of day", clock)
+	}
+	return ScheduleSpec{kind: scheduleRecipientLocal, hour: hour, minute: minute, zone: fallback}, nil
+}
+
+// OnDate pins a recipient-local schedule to a calendar date (YYYY-MM-DD)
+// instead of the next occurrence of the time
+func (s ScheduleSpec) OnDate(date string) ScheduleSpec {
+	s.date = date
+	return s
+}
+
+// IsRecipientLocal reports whether the send time depends on the recipient's zone
+func (s ScheduleSpec) IsRecipientLocal() bool {
+	return s.kind == scheduleRecipientLocal
+}
+
+func (s ScheduleSpec) clock() time.Time {
+	if s.now != nil {
+		return s.now()
+	}
+	return time.Now()
+}
+
+// Validate resolves the schedule for the fallback zone and checks that it is in the future
+func (s ScheduleSpec) Validate() error {
+	_, err := s.Resolve(nil)
+	return err
+}
+
+// Resolve returns the send time for a recipient in zone, or nil to send now.
+// zone only matters for recipient-local schedules.
+func (s ScheduleSpec) Resolve(zone *time.Location) (*time.Time, error) {
+	now := s.clock()
+	var at time.Time
+	switch s.kind {
+	case scheduleNone:
+		return nil, nil
+	case scheduleTime:
+		at = s.at
+	case scheduleNatural:
+		if strings.EqualFold(strings.TrimSpace(s.phrase), "now") {
+			return nil, nil
+		}
+		resolved, err := parseNaturalSchedule(s.phrase, now, s.zone)
+		if err != nil {
+			return nil, err
+		}
+		at = resolved
+	case scheduleRecipientLocal:
+		if zone == nil {
+			zone = s.zone
+		}
+		if zone == nil {
+			zone = time.UTC
+		}
+		local := now.In(zone)
+		year, month, day := local.Date()
+		if s.date != "" {
+			d, err := time.ParseInLocation(time.DateOnly, s.date, zone)
+			if err != nil {
+				return nil, fmt.Errorf("cannot parse %q as a date", s.date)
+			}
+			year, month, day = d.Date()
+		}
+		at = time.Date(year, month, day, s.hour, s.minute, 0, 0, zone)
+		if s.date == "" && !at.After(now) {
+			at = time.Date(year, month, day+1, s.hour, s.minute, 0, 0, zone)
+		}
+	}
+	if !at.After(now) {
+		return nil, fmt.Errorf("scheduled time %s is not in the future", at.Format(time.RFC3339))
+	}
+	return &at, nil
+}
+
+// CampaignScheduledAt converts the schedule for CreateCampaignJSONBody and
+// ScheduleCampaignJSONBody, which take an ISO 8601 string. Campaigns go out
+// at a single time, so recipient-local schedules are rejected.
+func (s ScheduleSpec) CampaignScheduledAt() (*string, error) {
+	if s.kind == scheduleRecipientLocal {
+		return nil, fmt.Errorf("campaigns cannot be scheduled in each recipient's time zone, send emails in batches instead")
+	}
+	at, err := s.Resolve(nil)
+	if err != nil || at == nil {
+		return nil, err
+	}
+	value := at.UTC().Format(time.RFC3339)
+	return &value, nil
+}
+
+// ApplyToEmail sets ScheduledAt on a single email for a recipient in zone
+func (s ScheduleSpec) ApplyToEmail(body *SendEmailJSONBody, zone *time.Location) error {
+	at, err := s.Resolve(zone)
+	if err != nil {
+		return err
+	}
+	body.ScheduledAt = at
+	return nil
+}
+
+// ScheduledBatch is a group of emails that share a send time
+type ScheduledBatch struct {
+	// At is nil for emails sent immediately
+	At     *time.Time
+	Emails SendBatchEmailsJSONBody
+}
+
+// SplitBatch sets ScheduledAt on every email of a batch and groups the
+// emails by send time, earliest first. zoneOf returns the time zone of the
+// i-th email's recipient, or nil when unknown; it may be nil for schedules
+// that do not depend on the recipient.
+func (s ScheduleSpec) SplitBatch(emails SendBatchEmailsJSONBody, zoneOf func(i int) *time.Location) ([]ScheduledBatch, error) {
+	groups := make(map[int64]*ScheduledBatch)
+	var order []int64
+	for i := range emails {
+		var zone *time.Location
+		if zoneOf != nil {
+			zone = zoneOf(i)
+		}
+		at, err := s.Resolve(zone)
+		if err != nil {
+			return nil, fmt.Errorf("email %d: %w", i, err)
+		}
+		emails[i].ScheduledAt = at
+		key := int64(-1)
+		if at != nil {
+			key = at.Unix()
+		}
+		group, ok := groups[key]
+		if !ok {
+			group = &ScheduledBatch{At: at}
+			groups[key] = group
+			order = append(order, key)
+		}
+		group.Emails = append(group.Emails, emails[i])
+	}
+	sort.Slice(order, func(i, j int) bool { return order[i] < order[j] })
+	batches := make([]ScheduledBatch, len(order))
+	for i, key := range order {
+		batches[i] = *groups[key]
+	}
+	return batches, nil
+}
+
+var (
+	relativeSchedulePattern = regexp.MustCompile(`^in (\d+) (minute|minutes|min|mins|hour|hours|hr|hrs|day|days|week|weeks)$`)
+	clockPattern            = regexp.MustCompile(`^(\d{1,2})(?::(\d{2}))?\s*(am|pm)?$`)
+	weekdays                = map[string]time.Weekday{
+		"sunday": time.Sunday, "monday": time.Monday, "tuesday": time.Tuesday, "wednesday": time.Wednesday,
+		"thursday": time.Thursday, "friday": time.Friday, "saturday": time.Saturday,
+	}
+)
+
+// parseNaturalSchedule understands ISO 8601 times and phrases of the form
+// "in N minutes|hours|days|weeks" and "[today|tomorrow|[next] <weekday>] [at] <time>"
+func parseNaturalSchedule(phrase string, now time.Time, zone *time.Location) (time.Time, error) {
+	if zone == nil {
+		zone = time.UTC
+	}
+	if t, err := ParseScheduleTime(phrase, zone); err == nil {
+		return t, nil
+	}
+	text := strings.Join(strings.Fields(strings.ToLower(phrase)), " ")
+	invalid := fmt.Errorf("cannot understand schedule %q", phrase)
+	if text == "" {
+		return time.Time{}, invalid
+	}
+	if m := relativeSchedulePattern.FindStringSubmatch(text); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		unit := map[byte]time.Duration{'m': time.Minute, 'h': time.Hour, 'd': 24 * time.Hour, 'w': 7 * 24 * time.Hour}[m[2][0]]
+		return now.Add(time.Duration(n) * unit), nil
+	}
+
+	local := now.In(zone)
+	year, month, day := local.Date()
+	words := strings.Fields(text)
+	dateGiven := false
+	switch {
+	case words[0] == "today":
+		words, dateGiven = words[1:], true
+	case words[0] == "tomorrow":
+		day++
+		words, dateGiven = words[1:], true
+	case words[0] == "next" && len(words) > 1:
+		if weekday, ok := weekdays[words[1]]; ok {
+			day += daysUntil(local.Weekday(), weekday)
+			words, dateGiven = words[2:], true
+		}
+	default:
+		if weekday, ok := weekdays[words[0]]; ok {
+			day += daysUntil(local.Weekday(), weekday)
+			words, dateGiven = words[1:], true
+		}
+	}
+	if len(words) > 0 && words[0] == "at" {
+		words = words[1:]
+	}
+
+	hour, minute := 9, 0
+	if len(words) > 0 {
+		var ok bool
+		if hour, minute, ok = parseClock(strings.Join(words, " ")); !ok {
+			return time.Time{}, invalid
+		}
+	} else if !dateGiven {
+		return time.Time{}, invalid
+	}
+	at := time.Date(year, month, day, hour, minute, 0, 0, zone)
+	if !dateGiven && !at.After(now) {
+		// a bare time such as "5pm" means the next occurrence
+		at = time.Date(year, month, day+1, hour, minute, 0, 0, zone)
+	}
+	return at, nil
+}
+
+// daysUntil returns the days from one weekday to the next occurrence of another, 1 to 7
+func daysUntil(from, to time.Weekday) int {
+	days := (int(to) - int(from) + 7) % 7
+	if days == 0 {
+		days = 7
+	}
+	return days
+}
+
+// parseClock reads "9am", "9:30 pm", "21:00", "noon" and "midnight"
+func parseClock(text string) (int, int, bool) {
+	switch text {
+	case "noon":
+		return 12, 0, true
+	case "midnight":
+		return 0, 0, true
+	}
+	m := clockPattern.FindStringSubmatch(text)
+	if m == nil {
+		return 0, 0, false
+	}
+	hour, _ := strconv.Atoi(m[1])
+	minute := 0
+	if m[2] != "" {
+		minute, _ = strconv.Atoi(m[2])
+	}
+	switch m[3] {
+	case "am", "pm":
+		if hour < 1 || hour > 12 {
+			return 0, 0, false
+		}
+		hour %= 12
+		if m[3] == "pm" {
+			hour += 12
+		}
+	default:
+		if m[2] == "" {
+			// a bare "9" is ambiguous
+			return 0, 0, false
+		}
+	}
+	if hour > 23 || minute > 59 {
+		return 0, 0, false
+	}
+	return hour, minute, true
+}
@@ -0,0 +1,116 @@
+package unsent
+
+import (
+	"testing"
+	"time"
+)
+
+// Wednesday 2025-01-15 14:30 UTC
+var scheduleNow = time.Date(2025, 1, 15, 14, 30, 0, 0, time.UTC)
+
+func fixedNow(spec ScheduleSpec) ScheduleSpec {
+	spec.now = func() time.Time { return scheduleNow }
+	return spec
+}
+
+func TestScheduleNatural(t *testing.T) {
+	tokyo := time.FixedZone("JST", 9*60*60)
+	cases := []struct {
+		phrase string
+		zone   *time.Location
+		want   time.Time
+	}{
+		{"in 90 minutes", nil, scheduleNow.Add(90 * time.Minute)},
+		{"in 2 days", nil, scheduleNow.Add(48 * time.Hour)},
+		{"tomorrow 9am", nil, time.Date(2025, 1, 16, 9, 0, 0, 0, time.UTC)},
+		{"Tomorrow at 5:15 PM", nil, time.Date(2025, 1, 16, 17, 15, 0, 0, time.UTC)},
+		{"today 18:00", nil, time.Date(2025, 1, 15, 18, 0, 0, 0, time.UTC)},
+		{"next monday 10:30", nil, time.Date(2025, 1, 20, 10, 30, 0, 0, time.UTC)},
+		{"wednesday noon", nil, time.Date(2025, 1, 22, 12, 0, 0, 0, time.UTC)},
+		{"friday", nil, time.Date(2025, 1, 17, 9, 0, 0, 0, time.UTC)},
+		{"2pm", nil, time.Date(2025, 1, 16, 14, 0, 0, 0, time.UTC)},
+		{"tomorrow 9am", tokyo, time.Date(2025, 1, 16, 0, 0, 0, 0, time.UTC)},
+		{"2025-02-01T10:00:00Z", nil, time.Date(2025, 2, 1, 10, 0, 0, 0, time.UTC)},
+	}
+	for _, c := range cases {
+		at, err := fixedNow(ScheduleNatural(c.phrase, c.zone)).Resolve(nil)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", c.phrase, err)
+			continue
+		}
+		if !at.Equal(c.want) {
+			t.Errorf("%q: expected %s, got %s", c.phrase, c.want, at.UTC())
+		}
+	}
+
+	for _, phrase := range []string{"", "someday", "tomorrow 25:00", "today 9am", "at 9", "2024-01-01T00:00:00Z"} {
+		if err := fixedNow(ScheduleNatural(phrase, nil)).Validate(); err == nil {
+			t.Errorf("%q: expected validation error", phrase)
+		}
+	}
+
+	if at, err := ScheduleNatural("now", nil).Resolve(nil); err != nil || at != nil {
+		t.Errorf("expected now to send immediately, got %v, %v", at, err)
+	}
+}
+
+func TestScheduleSpec_CampaignAndEmail(t *testing.T) {
+	value, err := fixedNow(ScheduleNatural("tomorrow 9am", nil)).CampaignScheduledAt()
+	if err != nil || *value != "2025-01-16T09:00:00Z" {
+		t.Errorf("expected ISO 8601 string, got %v, %v", value, err)
+	}
+
+	local, _ := ScheduleRecipientLocal("9am", nil)
+	if _, err := local.CampaignScheduledAt(); err == nil {
+		t.Error("expected recipient-local campaign schedule to be rejected")
+	}
+
+	var body SendEmailJSONBody
+	if err := fixedNow(ScheduleAt(scheduleNow.Add(time.Hour))).ApplyToEmail(&body, nil); err != nil || !body.ScheduledAt.Equal(scheduleNow.Add(time.Hour)) {
+		t.Errorf("expected ScheduledAt to be set, got %v, %v", body.ScheduledAt, err)
+	}
+	if err := fixedNow(ScheduleAt(scheduleNow.Add(-time.Hour))).ApplyToEmail(&body, nil); err == nil {
+		t.Error("expected past time to be rejected")
+	}
+}
+
+func TestScheduleSpec_SplitBatchByRecipientZone(t *testing.T) {
+	chicago := time.FixedZone("CST", -6*60*60)
+	berlin := time.FixedZone("CET", 60*60)
+	zones := []*time.Location{chicago, berlin, nil, chicago}
+
+	emails := make(SendBatchEmailsJSONBody, len(zones))
+	for i := range emails {
+		emails[i].From = "news@example.com"
+	}
+
+	spec, err := ScheduleRecipientLocal("09:00", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	batches, err := fixedNow(spec).SplitBatch(emails, func(i int) *time.Location { return zones[i] })
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// 14:30 UTC is past 9am in Berlin and UTC but still 8:30 in Chicago
+	want := []struct {
+		at    time.Time
+		count int
+	}{
+		{time.Date(2025, 1, 15, 15, 0, 0, 0, time.UTC), 2},
+		{time.Date(2025, 1, 16, 8, 0, 0, 0, time.UTC), 1},
+		{time.Date(2025, 1, 16, 9, 0, 0, 0, time.UTC), 1},
+	}
+	if len(batches) != len(want) {
+		t.Fatalf("expected %d batches, got %d", len(want), len(batches))
+	}
+	for i, w := range want {
+		if !batches[i].At.Equal(w.at) || len(batches[i].Emails) != w.count {
+			t.Errorf("batch %d: expected %d emails at %s, got %d at %s", i, w.count, w.at, len(batches[i].Emails), batches[i].At.UTC())
+		}
+	}
+	if !emails[0].ScheduledAt.Equal(want[0].at) {
+		t.Errorf("expected ScheduledAt to be set on the input batch")
+	}
+}
@@ -0,0 +1,501 @@
+package unsent
+
+import (
+	"context"
+	"fmt"
+	"net/mail"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// hoursPerWeek is the number of buckets in a send time profile
+const hoursPerWeek = 7 * 24
+
+// SendTimeAccountKey is the store key of the account-wide profile, used for
+// recipients without enough history of their own
+const SendTimeAccountKey = "*"
+
+// SendTimeProfile counts when a recipient opened past emails
+type SendTimeProfile struct {
+	Recipient string `json:"recipient"`
+	Opens     int    `json:"opens"`
+	// OpensByHour counts opens per UTC hour of the week, starting Sunday 00:00
+	OpensByHour [hoursPerWeek]int `json:"opensByHour"`
+	// Counted holds the opens counted per email ID, so learning from an
+	// email again replaces its opens instead of counting them twice
+	Counted   map[string][]time.Time `json:"counted,omitempty"`
+	UpdatedAt time.Time              `json:"updatedAt"`
+}
+
+func (p *SendTimeProfile) add(openedAt time.Time) {
+	openedAt = openedAt.UTC()
+	p.OpensByHour[int(openedAt.Weekday())*24+openedAt.Hour()]++
+	p.Opens++
+}
+
+func (p *SendTimeProfile) remove(openedAt time.Time) {
+	openedAt = openedAt.UTC()
+	if hour := int(openedAt.Weekday())*24 + openedAt.Hour(); p.OpensByHour[hour] > 0 {
+		p.OpensByHour[hour]--
+		p.Opens--
+	}
+}
+
+// count replaces the opens counted for emailID with opens. Counted is copied
+// rather than modified, since a loaded profile may share it with the store.
+func (p *SendTimeProfile) count(emailID string, opens []time.Time) {
+	for _, openedAt := range p.Counted[emailID] {
+		p.remove(openedAt)
+	}
+	for _, openedAt := range opens {
+		p.add(openedAt)
+	}
+	counted := make(map[string][]time.Time, len(p.Counted)+1)
+	for id, times := range p.Counted {
+		counted[id] = times
+	}
+	counted[emailID] = opens
+	p.Counted = counted
+}
+
+// PreferredHourOfWeek returns the UTC hour of the week (0 is Sunday 00:00)
+// with the most opens. Neighbouring hours count half so a single stray open
+// does not outweigh a consistent habit.
+func (p *SendTimeProfile) PreferredHourOfWeek() int {
+	return preferredBucket(p.OpensByHour[:])
+}
+
+// PreferredHourOfDay returns the UTC hour of the day with the most opens on any weekday
+func (p *SendTimeProfile) PreferredHourOfDay() int {
+	var byDay [24]int
+	for hour, opens := range p.OpensByHour {
+		byDay[hour%24] += opens
+	}
+	return preferredBucket(byDay[:])
+}
+
+func preferredBucket(counts []int) int {
+	n := len(counts)
+	best, bestScore := 0, -1
+	for i := range counts {
+		score := 2*counts[i] + counts[(i+n-1)%n] + counts[(i+1)%n]
+		if score > bestScore {
+			best, bestScore = i, score
+		}
+	}
+	return best
+}
+
+// SendTimeStore persists send time profiles, keyed by lower-cased recipient address
+type SendTimeStore interface {
+	// LoadProfile returns nil when the recipient has no profile
+	LoadProfile(ctx context.Context, recipient string) (*SendTimeProfile, error)
+	SaveProfile(ctx context.Context, profile SendTimeProfile) error
+}
+
+// MemorySendTimeStore keeps profiles in memory
+type MemorySendTimeStore struct {
+	mu       sync.Mutex
+	profiles map[string]SendTimeProfile
+}
+
+// LoadProfile implements SendTimeStore
+func (m *MemorySendTimeStore) LoadProfile(ctx context.Context, recipient string) (*SendTimeProfile, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	profile, ok := m.profiles[recipient]
+	if !ok {
+		return nil, nil
+	}
+	return &profile, nil
+}
+
+// SaveProfile implements SendTimeStore
+func (m *MemorySendTimeStore) SaveProfile(ctx context.Context, profile SendTimeProfile) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.profiles == nil {
+		m.profiles = make(map[string]SendTimeProfile)
+	}
+	m.profiles[profile.Recipient] = profile
+	return nil
+}
+
+// SendTimeOptions configures a SendTimeOptimizer
+type SendTimeOptions struct {
+	// Store persists profiles between runs, defaults to an in-memory store
+	Store SendTimeStore
+	// Lookback is how much open history Learn reads, defaults to 90 days
+	Lookback time.Duration
+	// MinOpens is the number of opens a profile needs before it is trusted, defaults to 3
+	MinOpens int
+	// MaxDelay caps how long a send is held back, defaults to 24 hours. When
+	// the preferred hour of the week is further away the preferred hour of
+	// the day is used, and when that is too, the email is sent immediately.
+	MaxDelay time.Duration
+	// Fallback is used when neither the recipient nor the account has enough
+	// history; the zero value sends immediately
+	Fallback ScheduleSpec
+	// PageSize is the number of events requested per page, defaults to 100
+	PageSize int
+	// Concurrency limits parallel email lookups, defaults to 4
+	Concurrency int
+}
+
+// SendTimeSource tells which profile a send time came from
+type SendTimeSource string
+
+const (
+	SendTimeSourceRecipient SendTimeSource = "recipient"
+	SendTimeSourceAccount   SendTimeSource = "account"
+	SendTimeSourceFallback  SendTimeSource = "fallback"
+)
+
+// SendTimeDecision is the send time chosen for a recipient
+type SendTimeDecision struct {
+	Recipient string
+	// At is nil when the email should go out immediately
+	At     *time.Time
+	Source SendTimeSource
+}
+
+// SendTimeOptimizer learns when recipients open email and schedules sends for then
+type SendTimeOptimizer struct {
+	client *Client
+	opts   SendTimeOptions
+
+	mu         sync.Mutex
+	recipients map[string][]string // email ID to recipients
+	now        func() time.Time
+}
+
+// NewSendTimeOptimizer creates an optimizer; call Learn to build profiles
+func NewSendTimeOptimizer(client *Client, opts SendTimeOptions) *SendTimeOptimizer {
+	if opts.Store == nil {
+		opts.Store = &MemorySendTimeStore{}
+	}
+	if opts.Lookback <= 0 {
+		opts.Lookback = 90 * 24 * time.Hour
+	}
+	if opts.MinOpens <= 0 {
+		opts.MinOpens = 3
+	}
+	if opts.MaxDelay <= 0 {
+		opts.MaxDelay = 24 * time.Hour
+	}
+	if opts.PageSize <= 0 {
+		opts.PageSize = 100
+	}
+	return &SendTimeOptimizer{
+		client:     client,
+		opts:       opts,
+		recipients: make(map[string][]string),
+		now:        time.Now,
+	}
+}
+
+// Learn reads every OPENED event within the lookback window and replaces
+// the stored profile of each recipient, plus the account-wide profile. It
+// returns the number of recipient profiles saved. Running it again over the
+// same window gives the same profiles, so it is safe to run on a schedule.
+func (o *SendTimeOptimizer) Learn(ctx context.Context) (int, error) {
+	since := o.now().Add(-o.opts.Lookback).UTC()
+	status := GetEventsParamsStatusOPENED
+	limit := o.opts.PageSize
+	seen := make(map[string]bool)
+	opensByEmail := make(map[string][]time.Time)
+	for page := 1; ; page++ {
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+		resp, apiErr := o.client.Events.List(GetEventsParams{Page: &page, Limit: &limit, Status: &status, StartDate: &since})
+		if apiErr != nil {
+			return 0, apiErr
+		}
+		// read until an empty page, since the server may cap the page size
+		if len(resp.Data) == 0 {
+			break
+		}
+		for _, event := range resp.Data {
+			if seen[event.ID] || !strings.EqualFold(event.Status, string(status)) || event.Timestamp.Before(since) {
+				continue
+			}
+			seen[event.ID] = true
+			opensByEmail[event.EmailID] = append(opensByEmail[event.EmailID], event.Timestamp)
+		}
+		if resp.Meta != nil && page >= resp.Meta.TotalPages {
+			break
+		}
+	}
+
+	emailIDs := make([]string, 0, len(opensByEmail))
+	for id := range opensByEmail {
+		emailIDs = append(emailIDs, id)
+	}
+	sort.Strings(emailIDs)
+	recipients, err := o.resolveRecipients(ctx, emailIDs)
+	if err != nil {
+		return 0, err
+	}
+	now := o.now()
+	account := SendTimeProfile{Recipient: SendTimeAccountKey, UpdatedAt: now}
+	profiles := make(map[string]*SendTimeProfile)
+	for i, id := range emailIDs {
+		if len(recipients[i]) == 0 {
+			continue
+		}
+		for _, openedAt := range opensByEmail[id] {
+			account.add(openedAt)
+		}
+		for _, recipient := range recipients[i] {
+			profile, ok := profiles[recipient]
+			if !ok {
+				profile = &SendTimeProfile{Recipient: recipient, UpdatedAt: now}
+				profiles[recipient] = profile
+			}
+			profile.count(id, opensByEmail[id])
+		}
+	}
+	for _, recipient := range sortedKeys(profiles) {
+		if err := o.opts.Store.SaveProfile(ctx, *profiles[recipient]); err != nil {
+			return 0, err
+		}
+	}
+	if err := o.opts.Store.SaveProfile(ctx, account); err != nil {
+		return 0, err
+	}
+	return len(profiles), nil
+}
+
+// LearnFromEmails merges the opens in the event timelines of the given
+// emails into their recipients' stored profiles, without touching the
+// account-wide profile. Opens already counted for an email, through Learn or
+// an earlier call, are recorded in the stored profile and replaced rather
+// than counted twice, also by a later process using the same store.
+// Use it to refresh a few recipients without reading the whole event log.
+func (o *SendTimeOptimizer) LearnFromEmails(ctx context.Context, emailIDs []string) (int, error) {
+	unique := make([]string, 0, len(emailIDs))
+	seen := make(map[string]bool, len(emailIDs))
+	for _, id := range emailIDs {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	emailIDs = unique
+	recipients, err := o.resolveRecipients(ctx, emailIDs)
+	if err != nil {
+		return 0, err
+	}
+	timelines := make([][]EmailEvent, len(emailIDs))
+	errs := make([]*APIError, len(emailIDs))
+	forEachConcurrent(len(emailIDs), o.opts.Concurrency, func(i int) {
+		timelines[i], errs[i] = o.client.Emails.Timeline(emailIDs[i])
+	})
+	for _, apiErr := range errs {
+		if apiErr != nil {
+			return 0, apiErr
+		}
+	}
+
+	// the opens of each email, grouped by recipient
+	opens := make(map[string]map[string][]time.Time)
+	for i, id := range emailIDs {
+		var emailOpens []time.Time
+		for _, event := range timelines[i] {
+			if event.Status == GetEmailEventsParamsStatusOPENED {
+				emailOpens = append(emailOpens, event.Timestamp)
+			}
+		}
+		for _, recipient := range recipients[i] {
+			if opens[recipient] == nil {
+				opens[recipient] = make(map[string][]time.Time)
+			}
+			opens[recipient][id] = emailOpens
+		}
+	}
+	return o.mergeProfiles(ctx, opens)
+}
+
+// mergeProfiles counts the opens of each email in its recipients' stored
+// profiles, replacing the opens counted for the same email before
+func (o *SendTimeOptimizer) mergeProfiles(ctx context.Context, opens map[string]map[string][]time.Time) (int, error) {
+	now := o.now()
+	recipients := sortedKeys(opens)
+	for _, recipient := range recipients {
+		profile := SendTimeProfile{Recipient: recipient}
+		stored, err := o.opts.Store.LoadProfile(ctx, recipient)
+		if err != nil {
+			return 0, err
+		}
+		if stored != nil {
+			profile = *stored
+		}
+		for _, id := range sortedKeys(opens[recipient]) {
+			profile.count(id, opens[recipient][id])
+		}
+		profile.UpdatedAt = now
+		if err := o.opts.Store.SaveProfile(ctx, profile); err != nil {
+			return 0, err
+		}
+	}
+	return len(recipients), nil
+}
+
+// resolveRecipients looks up the recipients of each email, caching results
+func (o *SendTimeOptimizer) resolveRecipients(ctx context.Context, emailIDs []string) ([][]string, error) {
+	recipients := make([][]string, len(emailIDs))
+	errs := make([]*APIError, len(emailIDs))
+	forEachConcurrent(len(emailIDs), o.opts.Concurrency, func(i int) {
+		if ctx.Err() != nil {
+			return
+		}
+		o.mu.Lock()
+		recipient, ok := o.recipients[emailIDs[i]]
+		o.mu.Unlock()
+		if !ok {
+			email, apiErr := o.client.Emails.Get(emailIDs[i])
+			if apiErr != nil {
+				errs[i] = apiErr
+				return
+			}
+			recipient = normalizeRecipients(email.To)
+			o.mu.Lock()
+			o.recipients[emailIDs[i]] = recipient
+			o.mu.Unlock()
+		}
+		recipients[i] = recipient
+	})
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	for _, apiErr := range errs {
+		if apiErr != nil {
+			return nil, apiErr
+		}
+	}
+	return recipients, nil
+}
+
+// SendTime chooses when to send to recipient: at their preferred hour when
+// they have enough history, else at the account's preferred hour, else per
+// the Fallback schedule
+func (o *SendTimeOptimizer) SendTime(ctx context.Context, recipient string) (SendTimeDecision, error) {
+	account, err := o.opts.Store.LoadProfile(ctx, SendTimeAccountKey)
+	if err != nil {
+		return SendTimeDecision{}, err
+	}
+	return o.decide(ctx, recipient, account, o.now())
+}
+
+func (o *SendTimeOptimizer) decide(ctx context.Context, recipient string, account *SendTimeProfile, now time.Time) (SendTimeDecision, error) {
+	decision := SendTimeDecision{Recipient: recipient}
+	var profile *SendTimeProfile
+	if key := normalizeRecipient(recipient); key != "" {
+		own, err := o.opts.Store.LoadProfile(ctx, key)
+		if err != nil {
+			return decision, err
+		}
+		if own != nil && own.Opens >= o.opts.MinOpens {
+			profile, decision.Source = own, SendTimeSourceRecipient
+		}
+	}
+	if profile == nil && account != nil && account.Opens >= o.opts.MinOpens {
+		profile, decision.Source = account, SendTimeSourceAccount
+	}
+	if profile == nil {
+		at, err := o.opts.Fallback.Resolve(nil)
+		decision.At, decision.Source = at, SendTimeSourceFallback
+		return decision, err
+	}
+
+	hour := now.UTC().Truncate(time.Hour)
+	current := int(hour.Weekday())*24 + hour.Hour()
+	wait := (profile.PreferredHourOfWeek() - current + hoursPerWeek) % hoursPerWeek
+	if time.Duration(wait)*time.Hour > o.opts.MaxDelay {
+		wait = (profile.PreferredHourOfDay() - hour.Hour() + 24) % 24
+	}
+	if wait > 0 && time.Duration(wait)*time.Hour <= o.opts.MaxDelay {
+		at := hour.Add(time.Duration(wait) * time.Hour)
+		decision.At = &at
+	}
+	return decision, nil
+}
+
+// ScheduleBatch sets ScheduledAt on each email of a batch from its first
+// recipient's profile and returns the decisions in batch order
+func (o *SendTimeOptimizer) ScheduleBatch(ctx context.Context, emails SendBatchEmailsJSONBody) ([]SendTimeDecision, error) {
+	account, err := o.opts.Store.LoadProfile(ctx, SendTimeAccountKey)
+	if err != nil {
+		return nil, err
+	}
+	now := o.now()
+	decisions := make([]SendTimeDecision, len(emails))
+	for i := range emails {
+		addresses, err := emails[i].To.Addresses()
+		if err != nil {
+			return nil, fmt.Errorf("email %d: %w", i, err)
+		}
+		var recipient string
+		if len(addresses) > 0 {
+			recipient = addresses[0]
+		}
+		decision, err := o.decide(ctx, recipient, account, now)
+		if err != nil {
+			return nil, err
+		}
+		emails[i].ScheduledAt = decision.At
+		decisions[i] = decision
+	}
+	return decisions, nil
+}
+
+// SendBatch schedules a batch with ScheduleBatch and sends it with Emails.Batch
+func (o *SendTimeOptimizer) SendBatch(ctx context.Context, emails SendBatchEmailsJSONBody, opts ...RequestOption) (*EmailBatchResponse, error) {
+	if _, err := o.ScheduleBatch(ctx, emails); err != nil {
+		return nil, err
+	}
+	resp, apiErr := o.client.Emails.Batch(emails, opts...)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	return resp, nil
+}
+
+// normalizeRecipient reduces "Jane <Jane@Example.com>" to "jane@example.com"
+func normalizeRecipient(value string) string {
+	value = strings.TrimSpace(value)
+	if addr, err := mail.ParseAddress(value); err == nil {
+		value = addr.Address
+	}
+	return strings.ToLower(value)
+}
+
+// normalizeRecipients splits a To field holding one or more addresses and
+// normalizes each, e.g. "Jane <jane@example.com>, bob@example.com"
+func normalizeRecipients(value string) []string {
+	var recipients []string
+	if list, err := mail.ParseAddressList(value); err == nil {
+		for _, addr := range list {
+			recipients = append(recipients, strings.ToLower(addr.Address))
+		}
+		return recipients
+	}
+	for _, part := range strings.Split(value, ",") {
+		if recipient := normalizeRecipient(part); recipient != "" {
+			recipients = append(recipients, recipient)
+		}
+	}
+	return recipients
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,218 @@
+package unsent
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newSendTimeServer(t *testing.T, batches *[]map[string]interface{}) *httptest.Server {
+	events := []string{
+		`{"id": "ev1", "emailId": "em1", "status": "OPENED", "timestamp": "2025-01-07T16:05:00Z"}`,
+		`{"id": "ev2", "emailId": "em2", "status": "OPENED", "timestamp": "2025-01-14T16:10:00Z"}`,
+		`{"id": "ev3", "emailId": "em2", "status": "OPENED", "timestamp": "2024-12-31T16:20:00Z"}`,
+		`{"id": "ev4", "emailId": "em3", "status": "OPENED", "timestamp": "2025-01-08T18:00:00Z"}`,
+		`{"id": "ev5", "emailId": "em3", "status": "OPENED", "timestamp": "2025-01-01T18:30:00Z"}`,
+		`{"id": "ev6", "emailId": "em3", "status": "OPENED", "timestamp": "2024-12-25T18:10:00Z"}`,
+		`{"id": "ev7", "emailId": "em3", "status": "OPENED", "timestamp": "2024-12-18T18:40:00Z"}`,
+		`{"id": "ev8", "emailId": "em4", "status": "OPENED", "timestamp": "2025-01-09T09:00:00Z"}`,
+		`{"id": "ev9", "emailId": "em5", "status": "OPENED", "timestamp": "2024-09-01T09:00:00Z"}`,
+	}
+	recipients := map[string]string{"em1": "Alice <Alice@example.com>", "em2": "alice@example.com", "em3": "bob@example.com", "em4": "carol@example.com", "em6": "Dan <Dan@example.com>, erin@example.com"}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/v1/events":
+			if r.URL.Query().Get("status") != "OPENED" {
+				t.Errorf("expected OPENED filter, got %s", r.URL.RawQuery)
+			}
+			page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+			limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+			if limit > 4 {
+				// the server caps the page size below what was requested
+				limit = 4
+			}
+			start, end := (page-1)*limit, page*limit
+			if start > len(events) {
+				start = len(events)
+			}
+			if end > len(events) {
+				end = len(events)
+			}
+			w.Write([]byte(`{"data": [` + strings.Join(events[start:end], ",") + `]}`))
+		case r.URL.Path == "/v1/emails/batch":
+			var body []map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&body)
+			*batches = append(*batches, body...)
+			w.Write([]byte(`{"data": [{"emailId": "new1"}, {"emailId": "new2"}, {"emailId": "new3"}]}`))
+		case strings.HasSuffix(r.URL.Path, "/events") && r.URL.Query().Get("page") != "1":
+			w.Write([]byte(`{"data": []}`))
+		case r.URL.Path == "/v1/emails/em4/events" || r.URL.Path == "/v1/emails/em6/events":
+			w.Write([]byte(`{"data": [
+				{"id": "e1", "status": "OPENED", "createdAt": "2025-01-13T07:00:00Z"},
+				{"id": "e2", "status": "OPENED", "createdAt": "2025-01-06T07:10:00Z"},
+				{"id": "e3", "status": "OPENED", "createdAt": "2024-12-30T07:20:00Z"},
+				{"id": "e4", "status": "DELIVERED", "createdAt": "2024-12-30T07:00:00Z"}
+			]}`))
+		case strings.HasPrefix(r.URL.Path, "/v1/emails/"):
+			id := strings.TrimPrefix(r.URL.Path, "/v1/emails/")
+			w.Write([]byte(`{"id": "` + id + `", "to": "` + recipients[id] + `"}`))
+		default:
+			t.Errorf("unexpected request %s", r.URL.Path)
+		}
+	}))
+}
+
+func TestSendTimeOptimizer_LearnAndSchedule(t *testing.T) {
+	var batches []map[string]interface{}
+	server := newSendTimeServer(t, &batches)
+	defer server.Close()
+	client, _ := NewClient("key", WithBaseURL(server.URL))
+
+	store := &MemorySendTimeStore{}
+	optimizer := NewSendTimeOptimizer(client, SendTimeOptions{Store: store, PageSize: 5})
+	optimizer.now = func() time.Time { return scheduleNow }
+
+	learned, err := optimizer.Learn(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if learned != 3 {
+		t.Errorf("expected 3 recipient profiles, got %d", learned)
+	}
+	alice, _ := store.LoadProfile(context.Background(), "alice@example.com")
+	if alice == nil || alice.Opens != 3 || alice.PreferredHourOfWeek() != 2*24+16 {
+		t.Errorf("unexpected profile for alice: %+v", alice)
+	}
+
+	emails := make(SendBatchEmailsJSONBody, 3)
+	for i, to := range []string{"alice@example.com", "bob@example.com", "carol@example.com"} {
+		emails[i].From = "digest@example.com"
+		emails[i].To = MakeBatchEmailTo(to)
+	}
+	decisions, err := optimizer.ScheduleBatch(context.Background(), emails)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []struct {
+		at     time.Time
+		source SendTimeSource
+	}{
+		// Tuesday 16:00 is six days away, so alice falls back to her hour of day
+		{time.Date(2025, 1, 15, 16, 0, 0, 0, time.UTC), SendTimeSourceRecipient},
+		{time.Date(2025, 1, 15, 18, 0, 0, 0, time.UTC), SendTimeSourceRecipient},
+		// carol has a single open, bob's habit dominates the account profile
+		{time.Date(2025, 1, 15, 18, 0, 0, 0, time.UTC), SendTimeSourceAccount},
+	}
+	for i, w := range want {
+		if decisions[i].Source != w.source || decisions[i].At == nil || !decisions[i].At.Equal(w.at) {
+			t.Errorf("email %d: expected %s from %s, got %+v", i, w.at, w.source, decisions[i])
+		}
+		if emails[i].ScheduledAt == nil || !emails[i].ScheduledAt.Equal(w.at) {
+			t.Errorf("email %d: expected ScheduledAt %s, got %v", i, w.at, emails[i].ScheduledAt)
+		}
+	}
+
+	if _, err := optimizer.LearnFromEmails(context.Background(), []string{"em4"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	decision, _ := optimizer.SendTime(context.Background(), "Carol@Example.com")
+	if decision.Source != SendTimeSourceRecipient || !decision.At.Equal(time.Date(2025, 1, 16, 7, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected carol's own profile after LearnFromEmails, got %+v", decision)
+	}
+
+	if _, err := optimizer.SendBatch(context.Background(), emails); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(batches) != 3 || batches[2]["scheduledAt"] != "2025-01-16T07:00:00Z" {
+		t.Errorf("unexpected batch body: %v", batches)
+	}
+}
+
+func TestSendTimeOptimizer_LearnFromEmailsMerges(t *testing.T) {
+	server := newSendTimeServer(t, nil)
+	defer server.Close()
+	client, _ := NewClient("key", WithBaseURL(server.URL))
+
+	store := &MemorySendTimeStore{}
+	earlier := SendTimeProfile{Recipient: "carol@example.com"}
+	for i := 0; i < 5; i++ {
+		earlier.add(time.Date(2025, 1, 6, 12, 0, 0, 0, time.UTC))
+	}
+	store.SaveProfile(context.Background(), earlier)
+	optimizer := NewSendTimeOptimizer(client, SendTimeOptions{Store: store})
+	optimizer.now = func() time.Time { return scheduleNow }
+
+	for run := 0; run < 2; run++ {
+		if _, err := optimizer.LearnFromEmails(context.Background(), []string{"em4", "em4"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		carol, _ := store.LoadProfile(context.Background(), "carol@example.com")
+		if carol.Opens != 8 || carol.OpensByHour[1*24+12] != 5 || carol.OpensByHour[1*24+7] != 3 {
+			t.Errorf("run %d: expected 3 new opens merged into 5 earlier ones, got %d opens", run+1, carol.Opens)
+		}
+	}
+}
+
+func TestSendTimeOptimizer_CountedAcrossProcesses(t *testing.T) {
+	server := newSendTimeServer(t, nil)
+	defer server.Close()
+	client, _ := NewClient("key", WithBaseURL(server.URL))
+
+	store := &MemorySendTimeStore{}
+	first := NewSendTimeOptimizer(client, SendTimeOptions{Store: store})
+	first.now = func() time.Time { return scheduleNow }
+	if _, err := first.Learn(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// a later process shares only the store
+	for run := 0; run < 2; run++ {
+		later := NewSendTimeOptimizer(client, SendTimeOptions{Store: store})
+		later.now = func() time.Time { return scheduleNow }
+		if _, err := later.LearnFromEmails(context.Background(), []string{"em4"}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		carol, _ := store.LoadProfile(context.Background(), "carol@example.com")
+		if carol.Opens != 3 || carol.OpensByHour[4*24+9] != 0 || len(carol.Counted["em4"]) != 3 {
+			t.Errorf("run %d: expected the opens from Learn to be replaced, got %+v", run+1, carol)
+		}
+	}
+}
+
+func TestSendTimeOptimizer_SeveralRecipients(t *testing.T) {
+	server := newSendTimeServer(t, nil)
+	defer server.Close()
+	client, _ := NewClient("key", WithBaseURL(server.URL))
+
+	store := &MemorySendTimeStore{}
+	optimizer := NewSendTimeOptimizer(client, SendTimeOptions{Store: store})
+	optimizer.now = func() time.Time { return scheduleNow }
+	if n, err := optimizer.LearnFromEmails(context.Background(), []string{"em6"}); err != nil || n != 2 {
+		t.Fatalf("expected 2 profiles, got %d, %v", n, err)
+	}
+	for _, recipient := range []string{"dan@example.com", "erin@example.com"} {
+		if profile, _ := store.LoadProfile(context.Background(), recipient); profile == nil || profile.Opens != 3 || len(profile.Counted["em6"]) != 3 {
+			t.Errorf("expected a profile for %s, got %+v", recipient, profile)
+		}
+	}
+}
+
+func TestSendTimeOptimizer_Fallback(t *testing.T) {
+	client, _ := NewClient("key", WithBaseURL("http://127.0.0.1:0"))
+	optimizer := NewSendTimeOptimizer(client, SendTimeOptions{})
+	decision, err := optimizer.SendTime(context.Background(), "new@example.com")
+	if err != nil || decision.Source != SendTimeSourceFallback || decision.At != nil {
+		t.Errorf("expected an immediate fallback send, got %+v, %v", decision, err)
+	}
+
+	optimizer = NewSendTimeOptimizer(client, SendTimeOptions{Fallback: ScheduleNatural("in 2 hours", nil)})
+	decision, err = optimizer.SendTime(context.Background(), "new@example.com")
+	if err != nil || decision.At == nil || decision.At.Before(time.Now().Add(time.Hour)) {
+		t.Errorf("expected the fallback schedule, got %+v, %v", decision, err)
+	}
+}
@@ -3,6 +3,7 @@ package unsent
 import (
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -53,3 +54,23 @@ func buildQueryParams(params map[string]interface{}) string {
 	}
 	return strings.Join(values, "&")
 }
+
+// forEachConcurrent calls fn for every index in [0, n) with at most limit
+// calls in flight. A non-positive limit defaults to 4.
+func forEachConcurrent(n, limit int, fn func(i int)) {
+	if limit <= 0 {
+		limit = 4
+	}
+	sem := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			fn(i)
+		}(i)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,150 @@
+package unsent
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"iter"
+	"strings"
+	"time"
+)
+
+// Webhook delivery statuses
+const (
+	WebhookDeliveryPending = "PENDING"
+	WebhookDeliverySuccess = "SUCCESS"
+	WebhookDeliveryFailed  = "FAILED"
+)
+
+// ListWebhookDeliveriesParams filters the deliveries of a webhook
+type ListWebhookDeliveriesParams struct {
+	Page      *int
+	Limit     *int
+	Status    *string
+	EventType *string
+	// From and To bound the creation time of the deliveries
+	From *time.Time
+	To   *time.Time
+}
+
+func (p ListWebhookDeliveriesParams) query() string {
+	var from, to *time.Time
+	if p.From != nil {
+		utc := p.From.UTC()
+		from = &utc
+	}
+	if p.To != nil {
+		utc := p.To.UTC()
+		to = &utc
+	}
+	return buildQueryParams(map[string]interface{}{
+		"page":      p.Page,
+		"limit":     p.Limit,
+		"status":    p.Status,
+		"eventType": p.EventType,
+		"startDate": from,
+		"endDate":   to,
+	})
+}
+
+// ListDeliveries retrieves a page of delivery attempts for a webhook
+func (w *WebhooksClient) ListDeliveries(webhookID string, params ListWebhookDeliveriesParams) (*WebhookDeliveriesResponse, *APIError) {
+	path := fmt.Sprintf("/webhooks/%s/deliveries", webhookID)
+	if query := params.query(); query != "" {
+		path = fmt.Sprintf("%s?%s", path, query)
+	}
+	return Get[WebhookDeliveriesResponse](w.client, path)
+}
+
+// GetDelivery retrieves a single delivery attempt including its payload
+func (w *WebhooksClient) GetDelivery(webhookID, deliveryID string) (*WebhookDelivery, *APIError) {
+	return Get[WebhookDelivery](w.client, fmt.Sprintf("/webhooks/%s/deliveries/%s", webhookID, deliveryID))
+}
+
+// Redeliver sends the payload of a delivery to the webhook again
+func (w *WebhooksClient) Redeliver(webhookID, deliveryID string) (*WebhookDelivery, *APIError) {
+	return Post[WebhookDelivery](w.client, fmt.Sprintf("/webhooks/%s/deliveries/%s/redeliver", webhookID, deliveryID), nil)
+}
+
+// Deliveries iterates over every delivery matching params, fetching pages
+// on demand. Page in params sets the first page; iteration stops after the
+// first error.
+func (w *WebhooksClient) Deliveries(webhookID string, params ListWebhookDeliveriesParams) iter.Seq2[WebhookDelivery, *APIError] {
+	return func(yield func(WebhookDelivery, *APIError) bool) {
+		page, limit := 1, 100
+		if params.Page != nil {
+			page = *params.Page
+		}
+		if params.Limit != nil {
+			limit = *params.Limit
+		}
+		// each iteration pages through its own copy so the Seq can be ranged over again
+		query := params
+		for ; ; page++ {
+			query.Page, query.Limit = &page, &limit
+			resp, err := w.ListDeliveries(webhookID, query)
+			if err != nil {
+				yield(WebhookDelivery{}, err)
+				return
+			}
+			for _, delivery := range resp.Data {
+				if !yield(delivery, nil) {
+					return
+				}
+			}
+			if len(resp.Data) == 0 || (resp.Meta != nil && page >= resp.Meta.TotalPages) || (resp.Meta == nil && len(resp.Data) < limit) {
+				return
+			}
+		}
+	}
+}
+
+// WebhookRedeliveryFailure records a delivery that could not be redelivered
+type WebhookRedeliveryFailure struct {
+	DeliveryID string
+	Err        *APIError
+}
+
+// WebhookRedeliveryResult reports the outcome of RedeliverFailed
+type WebhookRedeliveryResult struct {
+	Redelivered []WebhookDelivery
+	Failures    []WebhookRedeliveryFailure
+}
+
+// RedeliverFailed triggers redelivery of every failed delivery matching
+// params. The Status filter is forced to failed.
+func (w *WebhooksClient) RedeliverFailed(webhookID string, params ListWebhookDeliveriesParams) (*WebhookRedeliveryResult, error) {
+	status := WebhookDeliveryFailed
+	params.Status = &status
+	var failed []WebhookDelivery
+	for delivery, err := range w.Deliveries(webhookID, params) {
+		if err != nil {
+			return nil, err
+		}
+		failed = append(failed, delivery)
+	}
+
+	result := &WebhookRedeliveryResult{}
+	for _, delivery := range failed {
+		redelivered, err := w.Redeliver(webhookID, delivery.ID)
+		if err != nil {
+			result.Failures = append(result.Failures, WebhookRedeliveryFailure{DeliveryID: delivery.ID, Err: err})
+			continue
+		}
+		result.Redelivered = append(result.Redelivered, *redelivered)
+	}
+	return result, nil
+}
+
+// Failed reports whether the delivery attempt failed
+func (d *WebhookDelivery) Failed() bool {
+	return strings.EqualFold(d.Status, WebhookDeliveryFailed)
+}
+
+// DecodePayload unmarshals the JSON payload of the delivery into v
+func (d *WebhookDelivery) DecodePayload(v interface{}) error {
+	if d.Payload == "" {
+		return errors.New("webhook delivery has no payload")
+	}
+	return json.Unmarshal([]byte(d.Payload), v)
+}
@@ -0,0 +1,104 @@
+package unsent
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestWebhooks_ListAndGetDeliveries(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/webhooks/wh1/deliveries":
+			q := r.URL.Query()
+			if q.Get("status") != "FAILED" || q.Get("eventType") != "email.bounced" || q.Get("startDate") != "2024-01-01T00:00:00Z" {
+				t.Errorf("unexpected query: %s", r.URL.RawQuery)
+			}
+			w.Write([]byte(`{"data": [{"id": "del1", "status": "FAILED", "attempt": 3, "lastError": "timeout"}], "meta": {"total": 1, "page": 1, "limit": 20, "totalPages": 1}}`))
+		case "/v1/webhooks/wh1/deliveries/del1":
+			w.Write([]byte(`{"id": "del1", "status": "FAILED", "payload": "{\"type\": \"email.bounced\", \"emailId\": \"em1\"}"}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+	client, _ := NewClient("key", WithBaseURL(server.URL))
+
+	status, eventType := WebhookDeliveryFailed, "email.bounced"
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	resp, err := client.Webhooks.ListDeliveries("wh1", ListWebhookDeliveriesParams{Status: &status, EventType: &eventType, From: &from})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(resp.Data) != 1 || !resp.Data[0].Failed() || *resp.Data[0].LastError != "timeout" {
+		t.Errorf("unexpected deliveries: %+v", resp.Data)
+	}
+
+	delivery, err := client.Webhooks.GetDelivery("wh1", "del1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var payload struct {
+		Type    string `json:"type"`
+		EmailID string `json:"emailId"`
+	}
+	if err := delivery.DecodePayload(&payload); err != nil || payload.EmailID != "em1" {
+		t.Errorf("expected decoded payload, got %+v, %v", payload, err)
+	}
+}
+
+func TestWebhooks_DeliveriesIteratorAndRedeliverFailed(t *testing.T) {
+	var redelivered []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" {
+			var id string
+			fmt.Sscanf(r.URL.Path, "/v1/webhooks/wh1/deliveries/%3s", &id)
+			redelivered = append(redelivered, id)
+			if id == "d_3" {
+				w.WriteHeader(http.StatusConflict)
+				w.Write([]byte(`{"error": {"code": "CONFLICT", "message": "already delivering"}}`))
+				return
+			}
+			w.Write([]byte(`{"id": "` + id + `", "status": "PENDING"}`))
+			return
+		}
+		if r.URL.Query().Get("status") != "FAILED" {
+			t.Errorf("expected status filter, got %s", r.URL.RawQuery)
+		}
+		switch r.URL.Query().Get("page") {
+		case "1":
+			w.Write([]byte(`{"data": [{"id": "d_1"}, {"id": "d_2"}], "meta": {"page": 1, "totalPages": 2}}`))
+		case "2":
+			w.Write([]byte(`{"data": [{"id": "d_3"}], "meta": {"page": 2, "totalPages": 2}}`))
+		default:
+			t.Errorf("unexpected page %s", r.URL.Query().Get("page"))
+		}
+	}))
+	defer server.Close()
+	client, _ := NewClient("key", WithBaseURL(server.URL))
+
+	status := WebhookDeliveryFailed
+	deliveries := client.Webhooks.Deliveries("wh1", ListWebhookDeliveriesParams{Status: &status})
+	for run := 0; run < 2; run++ {
+		var ids []string
+		for delivery, err := range deliveries {
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			ids = append(ids, delivery.ID)
+		}
+		if len(ids) != 3 || ids[0] != "d_1" || ids[2] != "d_3" {
+			t.Errorf("expected 3 deliveries across pages on run %d, got %v", run+1, ids)
+		}
+	}
+
+	result, err := client.Webhooks.RedeliverFailed("wh1", ListWebhookDeliveriesParams{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(redelivered) != 3 || len(result.Redelivered) != 2 || len(result.Failures) != 1 || result.Failures[0].DeliveryID != "d_3" {
+		t.Errorf("unexpected result: %+v", result)
+	}
+}
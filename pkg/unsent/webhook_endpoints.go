@@ -0,0 +1,163 @@
+package unsent
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// WebhookEnsureResult reports what EnsureWebhook did to reach the spec
+type WebhookEnsureResult struct {
+	WebhookID string
+	Created   bool
+	Updated   bool
+	Changes   []FieldChange
+	// Secret is the signing secret of a newly created webhook
+	Secret string
+}
+
+// EnsureWebhook registers the webhook described by spec if no webhook with
+// the same URL exists, and otherwise reconciles its event types, active
+// flag and description. It is safe to call on every deploy. ctx is checked
+// before each request; a webhook that is being created is finished so it is
+// not left with the wrong active flag.
+func (w *WebhooksClient) EnsureWebhook(ctx context.Context, spec WebhookSpec) (*WebhookEnsureResult, error) {
+	if spec.Url == "" {
+		return nil, errors.New("webhook spec is missing its url")
+	}
+	if len(spec.EventTypes) == 0 {
+		return nil, errors.New("webhook spec has no event types")
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	webhooks, apiErr := w.List()
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	for _, live := range *webhooks {
+		if live.Url == spec.Url {
+			result, apiErr := w.reconcile(live, spec)
+			if apiErr != nil {
+				return nil, apiErr
+			}
+			return result, nil
+		}
+	}
+	result, apiErr := w.createFromSpec(spec)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	return result, nil
+}
+
+func (w *WebhooksClient) createFromSpec(spec WebhookSpec) (*WebhookEnsureResult, *APIError) {
+	eventTypes := make([]CreateWebhookJSONBodyEventTypes, len(spec.EventTypes))
+	for i, t := range spec.EventTypes {
+		eventTypes[i] = CreateWebhookJSONBodyEventTypes(t)
+	}
+	created, apiErr := w.Create(CreateWebhookJSONBody{Url: spec.Url, EventTypes: eventTypes, Description: spec.Description})
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if spec.Active != nil && !*spec.Active {
+		if _, apiErr := w.Update(created.ID, UpdateWebhookJSONBody{Active: spec.Active, Description: spec.Description}); apiErr != nil {
+			return nil, apiErr
+		}
+	}
+	return &WebhookEnsureResult{WebhookID: created.ID, Created: true, Secret: created.Secret}, nil
+}
+
+func (w *WebhooksClient) reconcile(live Webhook, spec WebhookSpec) (*WebhookEnsureResult, *APIError) {
+	result := &WebhookEnsureResult{WebhookID: live.ID, Changes: webhookChanges(live, spec)}
+	if len(result.Changes) == 0 {
+		return result, nil
+	}
+	if _, apiErr := w.Update(live.ID, webhookUpdateBody(live, spec)); apiErr != nil {
+		return nil, apiErr
+	}
+	result.Updated = true
+	return result, nil
+}
+
+// webhookUpdateBody builds the body that makes live match spec. The
+// description is always sent because the API treats a missing one as null.
+func webhookUpdateBody(live Webhook, spec WebhookSpec) UpdateWebhookJSONBody {
+	eventTypes := make([]UpdateWebhookJSONBodyEventTypes, len(spec.EventTypes))
+	for i, t := range spec.EventTypes {
+		eventTypes[i] = UpdateWebhookJSONBodyEventTypes(t)
+	}
+	description := spec.Description
+	if description == nil {
+		description = live.Description
+	}
+	return UpdateWebhookJSONBody{EventTypes: &eventTypes, Active: spec.Active, Description: description}
+}
+
+// WebhookRotateOptions configures WebhooksClient.RotateSecret
+type WebhookRotateOptions struct {
+	// Verifier, when set, is switched to the new secret and keeps accepting
+	// the previous one for Grace
+	Verifier *WebhookVerifier
+	Grace    time.Duration
+	// SendTest triggers a test delivery signed with the new secret
+	SendTest bool
+}
+
+// WebhookSecretRotation describes a completed secret rotation
+type WebhookSecretRotation struct {
+	WebhookID string
+	Secret    string
+	RotatedAt time.Time
+	// PreviousValidUntil is when the verifier stops accepting the old secret
+	PreviousValidUntil time.Time
+	Test               *WebhookTestResponse
+}
+
+// RotateSecret asks the API for a new signing secret and returns it. Update
+// the receiving endpoint with the new secret within the grace period; when a
+// Verifier is given it is updated in place. ctx is checked before each
+// request; once the secret has been rotated the rotation is returned along
+// with any later error, since the old secret no longer works.
+func (w *WebhooksClient) RotateSecret(ctx context.Context, webhookID string, opts WebhookRotateOptions) (*WebhookSecretRotation, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	live, apiErr := w.Get(webhookID)
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	rotate := true
+	resp, apiErr := w.Update(webhookID, UpdateWebhookJSONBody{RotateSecret: &rotate, Description: live.Description})
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	if resp.Secret == "" {
+		return nil, errors.New("webhook secret rotation response did not include the new secret")
+	}
+
+	rotation := &WebhookSecretRotation{WebhookID: webhookID, Secret: resp.Secret, RotatedAt: time.Now()}
+	if opts.Verifier != nil {
+		opts.Verifier.Rotate(resp.Secret, opts.Grace)
+		if opts.Grace > 0 {
+			rotation.PreviousValidUntil = rotation.RotatedAt.Add(opts.Grace)
+		}
+	}
+	if opts.SendTest {
+		if err := ctx.Err(); err != nil {
+			return rotation, err
+		}
+		test, apiErr := w.Test(webhookID)
+		if apiErr != nil {
+			return rotation, apiErr
+		}
+		rotation.Test = test
+	}
+	return rotation, nil
+}
@@ -0,0 +1,127 @@
+package unsent
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestWebhooks_EnsureWebhook(t *testing.T) {
+	var updates []UpdateWebhookJSONBody
+	created := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "GET /v1/webhooks":
+			w.Write([]byte(`[{"id": "wh1", "url": "https://api.example.com/hooks", "eventTypes": ["email.delivered"], "active": true, "description": "prod"}]`))
+		case "PATCH /v1/webhooks/wh1":
+			var body UpdateWebhookJSONBody
+			json.NewDecoder(r.Body).Decode(&body)
+			updates = append(updates, body)
+			w.Write([]byte(`{"success": true}`))
+		case "POST /v1/webhooks":
+			created = true
+			w.Write([]byte(`{"id": "wh2", "secret": "whsec_123"}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+	client, _ := NewClient("key", WithBaseURL(server.URL))
+	ctx := context.Background()
+
+	result, err := client.Webhooks.EnsureWebhook(ctx, WebhookSpec{Url: "https://api.example.com/hooks", EventTypes: []string{"email.delivered"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Created || result.Updated || len(updates) != 0 {
+		t.Errorf("expected no changes, got %+v", result)
+	}
+
+	result, err = client.Webhooks.EnsureWebhook(ctx, WebhookSpec{Url: "https://api.example.com/hooks", EventTypes: []string{"email.delivered", "email.bounced"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.Updated || len(updates) != 1 || len(*updates[0].EventTypes) != 2 {
+		t.Fatalf("expected event types update, got %+v", result)
+	}
+	if updates[0].Description == nil || *updates[0].Description != "prod" {
+		t.Errorf("expected existing description to be kept, got %v", updates[0].Description)
+	}
+
+	result, err = client.Webhooks.EnsureWebhook(ctx, WebhookSpec{Url: "https://staging.example.com/hooks", EventTypes: []string{"email.sent"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !created || !result.Created || result.WebhookID != "wh2" || result.Secret != "whsec_123" {
+		t.Errorf("expected webhook to be created, got %+v", result)
+	}
+}
+
+func TestWebhooks_RotateSecret(t *testing.T) {
+	tested := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "GET /v1/webhooks/wh1":
+			w.Write([]byte(`{"id": "wh1", "url": "https://api.example.com/hooks"}`))
+		case "PATCH /v1/webhooks/wh1":
+			var body UpdateWebhookJSONBody
+			json.NewDecoder(r.Body).Decode(&body)
+			if body.RotateSecret == nil || !*body.RotateSecret {
+				t.Errorf("expected rotateSecret to be true")
+			}
+			w.Write([]byte(`{"success": true, "secret": "whsec_new"}`))
+		case "POST /v1/webhooks/wh1/test":
+			tested = true
+			w.Write([]byte(`{"id": "del1", "status": "DELIVERED", "webhookId": "wh1"}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+	client, _ := NewClient("key", WithBaseURL(server.URL))
+
+	verifier := NewWebhookVerifier("whsec_old", testWebhookScheme)
+	rotation, err := client.Webhooks.RotateSecret(context.Background(), "wh1", WebhookRotateOptions{Verifier: verifier, Grace: time.Hour, SendTest: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rotation.Secret != "whsec_new" || rotation.PreviousValidUntil.IsZero() || !tested || rotation.Test.Status != "DELIVERED" {
+		t.Errorf("unexpected rotation: %+v", rotation)
+	}
+	if secrets := verifier.Secrets(); len(secrets) != 2 || secrets[0] != "whsec_new" || secrets[1] != "whsec_old" {
+		t.Errorf("expected verifier to hold both secrets, got %v", secrets)
+	}
+}
+
+func TestWebhooks_RotateSecretHonoursContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "GET /v1/webhooks/wh1":
+			w.Write([]byte(`{"id": "wh1", "url": "https://api.example.com/hooks"}`))
+		case "PATCH /v1/webhooks/wh1":
+			cancel()
+			w.Write([]byte(`{"success": true, "secret": "whsec_new"}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+	client, _ := NewClient("key", WithBaseURL(server.URL))
+
+	// the secret was rotated before the test delivery, so it is still returned
+	rotation, err := client.Webhooks.RotateSecret(ctx, "wh1", WebhookRotateOptions{SendTest: true})
+	if err != context.Canceled {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	if rotation == nil || rotation.Secret != "whsec_new" || rotation.Test != nil {
+		t.Errorf("expected the rotation without a test delivery, got %+v", rotation)
+	}
+
+	if _, err := client.Webhooks.RotateSecret(ctx, "wh1", WebhookRotateOptions{}); err != context.Canceled {
+		t.Errorf("expected a cancelled context to stop before any request, got %v", err)
+	}
+}
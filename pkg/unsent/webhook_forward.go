@@ -0,0 +1,240 @@
+package unsent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// WebhookForwardOptions configures ForwardWebhooks
+type WebhookForwardOptions struct {
+	// TargetURL is the local endpoint that receives the forwarded events
+	TargetURL string
+	// TunnelURL is a public URL that reaches ListenAddr. When empty, events
+	// are polled from the events API instead of registering a webhook.
+	TunnelURL string
+	// ListenAddr is where the tunnel delivers to, defaults to 127.0.0.1:8787
+	ListenAddr string
+	// EventTypes limits the forwarded events, defaults to every type
+	EventTypes []string
+	// Secret signs the forwarded requests with ForwardedWebhookScheme. When
+	// empty, forwarded requests are unsigned.
+	Secret string
+	// VerifyScheme, when set, verifies tunnelled deliveries against the
+	// temporary webhook's secret. By default they are forwarded unverified;
+	// rejected deliveries are reported to OnForward with the reason.
+	VerifyScheme *WebhookScheme
+	// PollOptions controls how often the events API is polled and how a
+	// failed forward of a polled event is retried
+	PollOptions
+	// HTTPClient sends the forwarded requests, defaults to http.DefaultClient
+	HTTPClient *http.Client
+	// OnForward is called after every forwarding attempt
+	OnForward func(ForwardedWebhook)
+}
+
+// ForwardedWebhook describes a single forwarding attempt
+type ForwardedWebhook struct {
+	EventID    string
+	EventType  string
+	StatusCode int
+	Duration   time.Duration
+	Err        error
+}
+
+// WebhookEventPayload is the body forwarded for events read from the events API
+type WebhookEventPayload struct {
+	ID        string                 `json:"id"`
+	Type      string                 `json:"type"`
+	CreatedAt time.Time              `json:"createdAt"`
+	Data      map[string]interface{} `json:"data"`
+}
+
+// ForwardWebhooks relays events to a local URL until ctx is cancelled. With
+// a TunnelURL it registers a temporary webhook pointing at the tunnel and
+// forwards each delivery; without one it polls the events API and retries a
+// failed forward until it succeeds, so no event is skipped. The temporary webhook is deleted before returning; if that fails the
+// delete error is joined into the returned error.
+func ForwardWebhooks(ctx context.Context, client *Client, opts WebhookForwardOptions) error {
+	if opts.TargetURL == "" {
+		return errors.New("webhook forwarding needs a target URL")
+	}
+	for _, eventType := range opts.EventTypes {
+		if !isWebhookEventType(eventType) {
+			return fmt.Errorf("unknown webhook event type %q", eventType)
+		}
+	}
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = http.DefaultClient
+	}
+	if opts.TunnelURL == "" {
+		return forwardPolledEvents(ctx, client, opts)
+	}
+	return forwardTunnelledWebhooks(ctx, client, opts)
+}
+
+func forwardTunnelledWebhooks(ctx context.Context, client *Client, opts WebhookForwardOptions) (err error) {
+	if opts.ListenAddr == "" {
+		opts.ListenAddr = "127.0.0.1:8787"
+	}
+	listener, err := net.Listen("tcp", opts.ListenAddr)
+	if err != nil {
+		return err
+	}
+
+	eventTypes := opts.EventTypes
+	if len(eventTypes) == 0 {
+		for _, t := range webhookEventTypes {
+			eventTypes = append(eventTypes, string(t))
+		}
+	}
+	description := "Temporary webhook for local forwarding"
+	created, apiErr := client.Webhooks.createFromSpec(WebhookSpec{Url: opts.TunnelURL, EventTypes: eventTypes, Description: &description})
+	if apiErr != nil {
+		listener.Close()
+		return apiErr
+	}
+	// a webhook left behind keeps pointing at a dead tunnel, so a failed
+	// delete is reported alongside the forwarding error
+	defer func() {
+		if _, apiErr := client.Webhooks.Delete(created.WebhookID); apiErr != nil {
+			err = errors.Join(err, fmt.Errorf("delete temporary webhook %s: %w", created.WebhookID, apiErr))
+		}
+	}()
+
+	var verifier *WebhookVerifier
+	if opts.VerifyScheme != nil {
+		verifier = NewWebhookVerifier(created.Secret, *opts.VerifyScheme)
+	}
+	reject := func(w http.ResponseWriter, status int, err error) {
+		if opts.OnForward != nil {
+			opts.OnForward(ForwardedWebhook{StatusCode: status, Err: err})
+		}
+		http.Error(w, err.Error(), status)
+	}
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body []byte
+		var err error
+		if verifier != nil {
+			body, err = verifier.VerifyRequest(r)
+		} else {
+			body, err = io.ReadAll(r.Body)
+		}
+		if err != nil {
+			reject(w, http.StatusUnauthorized, fmt.Errorf("rejected delivery: %w", err))
+			return
+		}
+		var event struct {
+			ID   string `json:"id"`
+			Type string `json:"type"`
+		}
+		if err := json.Unmarshal(body, &event); err != nil {
+			reject(w, http.StatusBadRequest, fmt.Errorf("invalid delivery body: %w", err))
+			return
+		}
+		status := forwardWebhookBody(r.Context(), opts, r.Header, body, event.ID, event.Type)
+		w.WriteHeader(status)
+	})}
+
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- server.Serve(listener) }()
+	select {
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+		return ctx.Err()
+	case err := <-serveErr:
+		return err
+	}
+}
+
+func forwardPolledEvents(ctx context.Context, client *Client, opts WebhookForwardOptions) error {
+	wanted := make(map[string]bool, len(opts.EventTypes))
+	for _, t := range opts.EventTypes {
+		wanted[t] = true
+	}
+	stream := NewEventStream(client, EventStreamOptions{PollOptions: opts.PollOptions})
+	return stream.Run(ctx, func(ctx context.Context, event StreamEvent) error {
+		payload := eventWebhookPayload(event)
+		if len(wanted) > 0 && !wanted[payload.Type] {
+			return nil
+		}
+		body, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		// the stream checkpoints once the handler returns, so keep retrying
+		retry := newBackoff(opts.PollOptions)
+		for {
+			status := forwardWebhookBody(ctx, opts, nil, body, payload.ID, payload.Type)
+			if status >= 200 && status < 300 {
+				return nil
+			}
+			if err := sleepContext(ctx, retry.next()); err != nil {
+				return err
+			}
+		}
+	})
+}
+
+// eventWebhookPayload shapes a polled event like a webhook delivery
+func eventWebhookPayload(event StreamEvent) WebhookEventPayload {
+	eventType := event.Type
+	if !strings.Contains(eventType, ".") {
+		status := event.Status
+		if status == "" {
+			status = event.Type
+		}
+		eventType = "email." + strings.ToLower(status)
+	}
+	data := make(map[string]interface{}, len(event.Data)+1)
+	for key, value := range event.Data {
+		data[key] = value
+	}
+	data["emailId"] = event.EmailID
+	return WebhookEventPayload{ID: event.ID, Type: eventType, CreatedAt: event.Timestamp, Data: data}
+}
+
+// forwardWebhookBody posts body to the target and returns the status to report upstream
+func forwardWebhookBody(ctx context.Context, opts WebhookForwardOptions, header http.Header, body []byte, eventID, eventType string) int {
+	started := time.Now()
+	result := ForwardedWebhook{EventID: eventID, EventType: eventType}
+	defer func() {
+		result.Duration = time.Since(started)
+		if opts.OnForward != nil {
+			opts.OnForward(result)
+		}
+	}()
+
+	req, err := http.NewRequestWithContext(ctx, "POST", opts.TargetURL, bytes.NewReader(body))
+	if err != nil {
+		result.Err = err
+		return http.StatusBadGateway
+	}
+	for key, values := range header {
+		if strings.HasPrefix(http.CanonicalHeaderKey(key), "X-Unsent-") {
+			req.Header[key] = values
+		}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if opts.Secret != "" {
+		ForwardedWebhookScheme.SetHeaders(req.Header, opts.Secret, time.Now(), body)
+	}
+
+	resp, err := opts.HTTPClient.Do(req)
+	if err != nil {
+		result.Err = err
+		return http.StatusBadGateway
+	}
+	resp.Body.Close()
+	result.StatusCode = resp.StatusCode
+	return resp.StatusCode
+}
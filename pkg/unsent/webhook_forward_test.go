@@ -0,0 +1,285 @@
+package unsent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestForwardWebhooks_Tunnel(t *testing.T) {
+	created := make(chan struct{})
+	deleted := make(chan struct{})
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "POST /v1/webhooks":
+			var body CreateWebhookJSONBody
+			json.NewDecoder(r.Body).Decode(&body)
+			if body.Url != "https://dev.tunnel.example/hooks" || len(body.EventTypes) != 1 {
+				t.Errorf("unexpected webhook body: %+v", body)
+			}
+			w.Write([]byte(`{"id": "wh_tmp", "secret": "whsec_tmp"}`))
+			close(created)
+		case "DELETE /v1/webhooks/wh_tmp":
+			w.Write([]byte(`{"success": true}`))
+			close(deleted)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer api.Close()
+
+	received := make(chan error, 1)
+	local := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := NewWebhookVerifier("whsec_local", ForwardedWebhookScheme).VerifyRequest(r)
+		received <- err
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer local.Close()
+
+	probe, _ := net.Listen("tcp", "127.0.0.1:0")
+	addr := probe.Addr().String()
+	probe.Close()
+
+	client, _ := NewClient("key", WithBaseURL(api.URL))
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	var forwarded []ForwardedWebhook
+	go func() {
+		done <- ForwardWebhooks(ctx, client, WebhookForwardOptions{
+			TargetURL:  local.URL,
+			TunnelURL:  "https://dev.tunnel.example/hooks",
+			ListenAddr: addr,
+			EventTypes: []string{"email.delivered"},
+			Secret:     "whsec_local",
+			OnForward:  func(f ForwardedWebhook) { forwarded = append(forwarded, f) },
+		})
+	}()
+	<-created
+
+	body := []byte(`{"id": "evt1", "type": "email.delivered"}`)
+	resp, err := http.Post("http://"+addr, "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.StatusCode != http.StatusAccepted {
+		t.Errorf("expected target status to be relayed, got %d", resp.StatusCode)
+	}
+	if err := <-received; err != nil {
+		t.Errorf("expected forwarded request to be signed with the local secret, got %v", err)
+	}
+
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	select {
+	case <-deleted:
+	default:
+		t.Error("expected temporary webhook to be deleted")
+	}
+	if len(forwarded) != 1 || forwarded[0].EventID != "evt1" || forwarded[0].StatusCode != http.StatusAccepted {
+		t.Errorf("unexpected forwarded events: %+v", forwarded)
+	}
+}
+
+func TestForwardWebhooks_PollFallback(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/events" || r.URL.Query().Get("startDate") == "" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		ts := time.Now().UTC().Add(time.Second).Format(time.RFC3339Nano)
+		w.Write([]byte(`{"data": [
+			{"id": "evt1", "emailId": "em1", "status": "DELIVERED", "timestamp": "` + ts + `"},
+			{"id": "evt2", "emailId": "em2", "status": "OPENED", "timestamp": "` + ts + `"}
+		]}`))
+	}))
+	defer api.Close()
+
+	var mu sync.Mutex
+	var payloads []WebhookEventPayload
+	local := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var payload WebhookEventPayload
+		json.Unmarshal(body, &payload)
+		mu.Lock()
+		payloads = append(payloads, payload)
+		mu.Unlock()
+	}))
+	defer local.Close()
+
+	client, _ := NewClient("key", WithBaseURL(api.URL))
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := ForwardWebhooks(ctx, client, WebhookForwardOptions{
+		TargetURL:   local.URL,
+		EventTypes:  []string{"email.delivered"},
+		PollOptions: PollOptions{Interval: time.Millisecond, MaxInterval: 5 * time.Millisecond},
+	})
+	if err != context.DeadlineExceeded {
+		t.Errorf("expected context.DeadlineExceeded, got %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(payloads) != 1 || payloads[0].Type != "email.delivered" || payloads[0].Data["emailId"] != "em1" {
+		t.Errorf("expected the delivered event to be forwarded once, got %+v", payloads)
+	}
+}
+
+func TestForwardWebhooks_ReportsCleanupFailure(t *testing.T) {
+	created := make(chan struct{})
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "POST /v1/webhooks":
+			w.Write([]byte(`{"id": "wh_tmp"}`))
+			close(created)
+		case "DELETE /v1/webhooks/wh_tmp":
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`{"error": {"code": "INTERNAL_SERVER_ERROR", "message": "unavailable"}}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer api.Close()
+
+	client, _ := NewClient("key", WithBaseURL(api.URL))
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- ForwardWebhooks(ctx, client, WebhookForwardOptions{
+			TargetURL:  "http://127.0.0.1:1",
+			TunnelURL:  "https://dev.tunnel.example/hooks",
+			ListenAddr: "127.0.0.1:0",
+		})
+	}()
+	<-created
+	cancel()
+
+	err := <-done
+	if !errors.Is(err, context.Canceled) || !strings.Contains(err.Error(), "delete temporary webhook wh_tmp") {
+		t.Errorf("expected the cleanup failure to be reported, got %v", err)
+	}
+}
+
+func TestForwardWebhooks_TunnelVerifyOptIn(t *testing.T) {
+	created := make(chan struct{})
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "POST /v1/webhooks":
+			w.Write([]byte(`{"id": "wh_tmp", "secret": "whsec_tmp"}`))
+			close(created)
+		case "DELETE /v1/webhooks/wh_tmp":
+			w.Write([]byte(`{"success": true}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer api.Close()
+	local := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer local.Close()
+
+	probe, _ := net.Listen("tcp", "127.0.0.1:0")
+	addr := probe.Addr().String()
+	probe.Close()
+
+	client, _ := NewClient("key", WithBaseURL(api.URL))
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	var mu sync.Mutex
+	var forwarded []ForwardedWebhook
+	go func() {
+		done <- ForwardWebhooks(ctx, client, WebhookForwardOptions{
+			TargetURL:    local.URL,
+			TunnelURL:    "https://dev.tunnel.example/hooks",
+			ListenAddr:   addr,
+			VerifyScheme: &testWebhookScheme,
+			OnForward: func(f ForwardedWebhook) {
+				mu.Lock()
+				forwarded = append(forwarded, f)
+				mu.Unlock()
+			},
+		})
+	}()
+	<-created
+
+	send := func(body []byte, sign bool) int {
+		req, _ := http.NewRequest("POST", "http://"+addr, bytes.NewReader(body))
+		if sign {
+			testWebhookScheme.SetHeaders(req.Header, "whsec_tmp", time.Now(), body)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	body := []byte(`{"id": "evt1", "type": "email.delivered"}`)
+	if status := send(body, false); status != http.StatusUnauthorized {
+		t.Errorf("expected an unsigned delivery to be rejected, got %d", status)
+	}
+	if status := send([]byte(`not json`), true); status != http.StatusBadRequest {
+		t.Errorf("expected an invalid body to be rejected, got %d", status)
+	}
+	if status := send(body, true); status != http.StatusAccepted {
+		t.Errorf("expected a signed delivery to be forwarded, got %d", status)
+	}
+	cancel()
+	<-done
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(forwarded) != 3 || !errors.Is(forwarded[0].Err, ErrWebhookSignatureMissing) || forwarded[1].StatusCode != http.StatusBadRequest || forwarded[2].EventID != "evt1" {
+		t.Errorf("expected the rejections to be reported, got %+v", forwarded)
+	}
+}
+
+func TestForwardWebhooks_PollRetriesFailedForward(t *testing.T) {
+	ts := time.Now().UTC().Add(time.Second).Format(time.RFC3339Nano)
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data": [
+			{"id": "evt2", "emailId": "em2", "status": "OPENED", "timestamp": "` + ts + `"},
+			{"id": "evt1", "emailId": "em1", "status": "DELIVERED", "timestamp": "` + ts + `"}
+		]}`))
+	}))
+	defer api.Close()
+
+	var mu sync.Mutex
+	var received []string
+	local := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload WebhookEventPayload
+		json.NewDecoder(r.Body).Decode(&payload)
+		mu.Lock()
+		defer mu.Unlock()
+		received = append(received, payload.ID)
+		if len(received) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer local.Close()
+
+	client, _ := NewClient("key", WithBaseURL(api.URL))
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	ForwardWebhooks(ctx, client, WebhookForwardOptions{
+		TargetURL:   local.URL,
+		PollOptions: PollOptions{Interval: time.Millisecond, MaxInterval: 5 * time.Millisecond},
+	})
+
+	mu.Lock()
+	defer mu.Unlock()
+	if strings.Join(received, ",") != "evt1,evt1,evt2" {
+		t.Errorf("expected the failed forward to be retried before moving on, got %v", received)
+	}
+}
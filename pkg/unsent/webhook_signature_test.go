@@ -0,0 +1,111 @@
+package unsent
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+var testWebhookScheme = HMACWebhookScheme("X-Test-Signature", "X-Test-Timestamp")
+
+func TestWebhookVerifier_Verify(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	verifier := NewWebhookVerifier("whsec_old", testWebhookScheme)
+	verifier.now = func() time.Time { return now }
+	body := []byte(`{"type": "email.delivered"}`)
+
+	header := http.Header{}
+	testWebhookScheme.SetHeaders(header, "whsec_old", now, body)
+	if err := verifier.Verify(header, body); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := verifier.Verify(header, []byte(`{"type": "email.bounced"}`)); err != ErrWebhookSignatureInvalid {
+		t.Errorf("expected ErrWebhookSignatureInvalid for a tampered body, got %v", err)
+	}
+	if err := verifier.Verify(http.Header{}, body); err != ErrWebhookSignatureMissing {
+		t.Errorf("expected ErrWebhookSignatureMissing, got %v", err)
+	}
+
+	stale := http.Header{}
+	testWebhookScheme.SetHeaders(stale, "whsec_old", now.Add(-10*time.Minute), body)
+	if err := verifier.Verify(stale, body); err != ErrWebhookTimestampExpired {
+		t.Errorf("expected ErrWebhookTimestampExpired, got %v", err)
+	}
+
+	req := httptest.NewRequest("POST", "/hooks", bytes.NewReader(body))
+	req.Header = header
+	got, err := verifier.VerifyRequest(req)
+	if err != nil || !bytes.Equal(got, body) {
+		t.Errorf("expected verified body, got %q, %v", got, err)
+	}
+}
+
+func TestWebhookVerifier_RotateGracePeriod(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	verifier := NewWebhookVerifier("whsec_old", testWebhookScheme)
+	verifier.now = func() time.Time { return now }
+	body := []byte(`{}`)
+
+	verifier.Rotate("whsec_new", time.Hour)
+	if secrets := verifier.Secrets(); len(secrets) != 2 || secrets[0] != "whsec_new" {
+		t.Errorf("expected new and old secrets, got %v", secrets)
+	}
+	for _, secret := range []string{"whsec_old", "whsec_new"} {
+		header := http.Header{}
+		testWebhookScheme.SetHeaders(header, secret, now, body)
+		if err := verifier.Verify(header, body); err != nil {
+			t.Errorf("expected %s to validate during the grace period, got %v", secret, err)
+		}
+	}
+
+	now = now.Add(2 * time.Hour)
+	header := http.Header{}
+	testWebhookScheme.SetHeaders(header, "whsec_old", now, body)
+	if err := verifier.Verify(header, body); err != ErrWebhookSignatureInvalid {
+		t.Errorf("expected old secret to be rejected after the grace period, got %v", err)
+	}
+
+	verifier.Rotate("whsec_newer", 0)
+	if secrets := verifier.Secrets(); len(secrets) != 1 || secrets[0] != "whsec_newer" {
+		t.Errorf("expected only the newest secret, got %v", secrets)
+	}
+}
+
+func TestWebhookVerifier_ZeroValue(t *testing.T) {
+	var verifier WebhookVerifier
+	body := []byte(`{"type": "email.delivered"}`)
+	header := http.Header{}
+	testWebhookScheme.SetHeaders(header, "whsec_new", time.Now(), body)
+	if err := verifier.Verify(header, body); err != ErrWebhookSchemeMissing {
+		t.Errorf("expected a verifier without a scheme to reject, got %v", err)
+	}
+	verifier.Scheme = testWebhookScheme
+	if err := verifier.Verify(header, body); err != ErrWebhookSignatureInvalid {
+		t.Errorf("expected a verifier without secrets to reject, got %v", err)
+	}
+	verifier.Rotate("whsec_new", time.Hour)
+	if err := verifier.Verify(header, body); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestWebhookVerifier_CustomScheme(t *testing.T) {
+	now := time.Now()
+	verifier := NewWebhookVerifier("whsec", WebhookScheme{
+		SignatureHeader: "Webhook-Signature",
+		TimestampHeader: "Webhook-Timestamp",
+		Sign: func(secret string, timestamp time.Time, body []byte) string {
+			return "v2=" + SignWebhookPayload(secret, timestamp, body)[3:]
+		},
+	})
+	body := []byte(`{}`)
+	header := http.Header{}
+	header.Set("Webhook-Timestamp", strconv.FormatInt(now.Unix(), 10))
+	header.Set("Webhook-Signature", "v2="+SignWebhookPayload("whsec", now, body)[3:])
+	if err := verifier.Verify(header, body); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
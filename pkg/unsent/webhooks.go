@@ -33,22 +33,7 @@ func (w *WebhooksClient) Delete(webhookID string) (*WebhookDeleteResponse, *APIE
 }
 
 // WebhookTestResponse represents the response from testing a webhook
-type WebhookTestResponse struct {
-	ID             string   `json:"id"`
-	Type           string   `json:"type"`
-	CreatedAt      string   `json:"createdAt"`
-	UpdatedAt      string   `json:"updatedAt"`
-	TeamID         string   `json:"teamId"`
-	Status         string   `json:"status"`
-	WebhookID      string   `json:"webhookId"`
-	Payload        string   `json:"payload"`
-	Attempt        float32  `json:"attempt"`
-	NextAttemptAt  *string  `json:"nextAttemptAt,omitempty"`
-	LastError      *string  `json:"lastError,omitempty"`
-	ResponseStatus *float32 `json:"responseStatus,omitempty"`
-	ResponseTimeMs *float32 `json:"responseTimeMs,omitempty"`
-	ResponseText   *string  `json:"responseText,omitempty"`
-}
+type WebhookTestResponse = WebhookDelivery
 
 // Test triggers a test event for a webhook
 func (w *WebhooksClient) Test(webhookID string) (*WebhookTestResponse, *APIError) {
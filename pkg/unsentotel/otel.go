@@ -0,0 +1,244 @@
+// Package unsentotel adds OpenTelemetry tracing and metrics to an unsent
+// Client. It lives in its own module so the core SDK does not depend on
+// OpenTelemetry.
+//
+//	instrument, err := unsentotel.Instrument(unsentotel.Options{})
+//	if err != nil {
+//		return err
+//	}
+//	client, err := unsent.NewClient("un_xxx", instrument)
+//
+// Every API call becomes a client span named after its operation, such as
+// "unsent.emails.create", and is counted in these instruments:
+//
+//   - unsent.client.requests: requests by operation, status code and error code
+//   - unsent.client.request.duration: request latency in seconds
+//   - unsent.emails.sent: emails accepted by the API, by sender domain
+//
+// The client sends each request once, so spans never carry
+// http.request.resend_count. Retries made by a transport wrapped by this
+// one appear as separate spans.
+package unsentotel
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/souravsspace/unsent-go/pkg/unsent"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ScopeName is the instrumentation scope of the tracer and meter
+const ScopeName = "github.com/souravsspace/unsent-go/pkg/unsentotel"
+
+// Attribute keys set on spans and metrics
+const (
+	AttrOperation = attribute.Key("unsent.operation")
+	AttrErrorCode = attribute.Key("unsent.error.code")
+	AttrDomain    = attribute.Key("unsent.domain")
+)
+
+// Options configures the instrumentation. Zero values use the global providers.
+type Options struct {
+	TracerProvider trace.TracerProvider
+	MeterProvider  metric.MeterProvider
+	Propagator     propagation.TextMapPropagator
+	// Base is the transport being instrumented, defaults to the client's
+	// transport or http.DefaultTransport
+	Base http.RoundTripper
+}
+
+// Transport is an http.RoundTripper that traces and measures Unsent API calls
+type Transport struct {
+	base       http.RoundTripper
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+	requests   metric.Int64Counter
+	duration   metric.Float64Histogram
+	emailsSent metric.Int64Counter
+}
+
+// NewTransport creates an instrumented transport
+func NewTransport(opts Options) (*Transport, error) {
+	if opts.TracerProvider == nil {
+		opts.TracerProvider = otel.GetTracerProvider()
+	}
+	if opts.MeterProvider == nil {
+		opts.MeterProvider = otel.GetMeterProvider()
+	}
+	if opts.Propagator == nil {
+		opts.Propagator = otel.GetTextMapPropagator()
+	}
+	if opts.Base == nil {
+		opts.Base = http.DefaultTransport
+	}
+	meter := opts.MeterProvider.Meter(ScopeName)
+	requests, err := meter.Int64Counter("unsent.client.requests",
+		metric.WithDescription("Unsent API requests"), metric.WithUnit("{request}"))
+	if err != nil {
+		return nil, err
+	}
+	duration, err := meter.Float64Histogram("unsent.client.request.duration",
+		metric.WithDescription("Duration of Unsent API requests"), metric.WithUnit("s"))
+	if err != nil {
+		return nil, err
+	}
+	emailsSent, err := meter.Int64Counter("unsent.emails.sent",
+		metric.WithDescription("Emails accepted by the Unsent API"), metric.WithUnit("{email}"))
+	if err != nil {
+		return nil, err
+	}
+	return &Transport{
+		base:       opts.Base,
+		tracer:     opts.TracerProvider.Tracer(ScopeName),
+		propagator: opts.Propagator,
+		requests:   requests,
+		duration:   duration,
+		emailsSent: emailsSent,
+	}, nil
+}
+
+// Instrument returns a client option that wraps the client's transport, or
+// the error of creating the instruments. Pass the option after
+// WithHTTPClient, which would otherwise replace the instrumented client. The
+// HTTP client is copied, so a shared *http.Client is not modified.
+func Instrument(opts Options) (unsent.ClientOption, error) {
+	clientBase := opts.Base == nil
+	transport, err := NewTransport(opts)
+	if err != nil {
+		return nil, err
+	}
+	return func(c *unsent.Client) {
+		httpClient := *c.HTTPClient
+		instrumented := *transport
+		if clientBase && httpClient.Transport != nil {
+			instrumented.base = httpClient.Transport
+		}
+		httpClient.Transport = &instrumented
+		c.HTTPClient = &httpClient
+	}, nil
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	route := unsent.MatchRoute(req.Method, req.URL.Path)
+	attrs := []attribute.KeyValue{
+		AttrOperation.String(route.Operation),
+		attribute.String("http.request.method", req.Method),
+	}
+	if route.Template != "" {
+		attrs = append(attrs, attribute.String("url.template", route.Template))
+	}
+
+	ctx, span := t.tracer.Start(req.Context(), route.Operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+		trace.WithAttributes(attribute.String("server.address", req.URL.Hostname())))
+	defer span.End()
+
+	req = req.Clone(ctx)
+	t.propagator.Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	elapsed := time.Since(start).Seconds()
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		attrs = append(attrs, attribute.String("error.type", "transport"))
+		t.record(ctx, attrs, elapsed)
+		return nil, err
+	}
+
+	outcome := []attribute.KeyValue{attribute.Int("http.response.status_code", resp.StatusCode)}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		code := peekErrorCode(resp)
+		if code == "" {
+			code = http.StatusText(resp.StatusCode)
+		}
+		outcome = append(outcome, AttrErrorCode.String(code), attribute.String("error.type", code))
+		span.SetStatus(codes.Error, code)
+	} else if route.Operation == "unsent.emails.create" || route.Operation == "unsent.emails.batch" {
+		for domain, count := range senderDomains(req) {
+			t.emailsSent.Add(ctx, int64(count), metric.WithAttributes(AttrDomain.String(domain)))
+		}
+	}
+	span.SetAttributes(outcome...)
+	t.record(ctx, append(attrs, outcome...), elapsed)
+	return resp, nil
+}
+
+func (t *Transport) record(ctx context.Context, attrs []attribute.KeyValue, seconds float64) {
+	set := metric.WithAttributes(attrs...)
+	t.requests.Add(ctx, 1, set)
+	t.duration.Record(ctx, seconds, set)
+}
+
+// peekErrorCode reads the code of an API error body and restores the body
+func peekErrorCode(resp *http.Response) string {
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return ""
+	}
+	var flat unsent.APIError
+	if json.Unmarshal(body, &flat) == nil && flat.Code != "" {
+		return flat.Code
+	}
+	var nested struct {
+		Error unsent.APIError `json:"error"`
+	}
+	json.Unmarshal(body, &nested)
+	return nested.Error.Code
+}
+
+// senderDomains counts the emails of a send or batch request by the domain
+// of their from address
+func senderDomains(req *http.Request) map[string]int {
+	if req.GetBody == nil {
+		return nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil
+	}
+	defer body.Close()
+	raw, err := io.ReadAll(body)
+	if err != nil {
+		return nil
+	}
+	type sender struct {
+		From string `json:"from"`
+	}
+	var emails []sender
+	if err := json.Unmarshal(raw, &emails); err != nil {
+		var single sender
+		if json.Unmarshal(raw, &single) != nil {
+			return nil
+		}
+		emails = []sender{single}
+	}
+	domains := make(map[string]int)
+	for _, email := range emails {
+		address := email.From
+		if parsed, err := mail.ParseAddress(address); err == nil {
+			address = parsed.Address
+		}
+		if i := strings.LastIndexByte(address, '@'); i >= 0 {
+			domains[strings.ToLower(address[i+1:])]++
+		}
+	}
+	return domains
+}
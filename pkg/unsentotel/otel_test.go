@@ -0,0 +1,122 @@
+package unsentotel
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/souravsspace/unsent-go/pkg/unsent"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/noop"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestInstrument(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/emails/batch":
+			w.Write([]byte(`{"data": [{"emailId": "em1"}, {"emailId": "em2"}, {"emailId": "em3"}]}`))
+		case "/v1/emails/em_missing":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error": {"code": "NOT_FOUND", "message": "email not found"}}`))
+		default:
+			w.Write([]byte(`{"emailId": "em1"}`))
+		}
+	}))
+	defer server.Close()
+
+	spans := tracetest.NewSpanRecorder()
+	reader := sdkmetric.NewManualReader()
+	instrument, err := Instrument(Options{
+		TracerProvider: sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans)),
+		MeterProvider:  sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	client, _ := unsent.NewClient("key", unsent.WithBaseURL(server.URL), instrument)
+
+	batch := make(unsent.SendBatchEmailsJSONBody, 3)
+	for i, from := range []string{"news@a.com", "Billing <billing@A.com>", "hi@b.com"} {
+		batch[i].From = from
+		batch[i].To = unsent.MakeBatchEmailTo("user@example.com")
+	}
+	if _, err := client.Emails.Batch(batch); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := client.Emails.Get("em_missing"); err == nil || err.Code != "NOT_FOUND" {
+		t.Fatalf("expected the error body to reach the caller, got %v", err)
+	}
+
+	ended := spans.Ended()
+	if len(ended) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(ended))
+	}
+	if ended[0].Name() != "unsent.emails.batch" || ended[1].Name() != "unsent.emails.get" {
+		t.Errorf("unexpected span names %s, %s", ended[0].Name(), ended[1].Name())
+	}
+	failed := attributeMap(ended[1].Attributes())
+	if failed["url.template"] != "/emails/{emailId}" || failed["unsent.error.code"] != "NOT_FOUND" || failed["http.response.status_code"] != "404" {
+		t.Errorf("unexpected attributes: %v", failed)
+	}
+	if ended[1].Status().Code != codes.Error {
+		t.Errorf("expected error status, got %v", ended[1].Status())
+	}
+
+	var data metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &data); err != nil {
+		t.Fatalf("collect: %v", err)
+	}
+	sent := make(map[string]int64)
+	var requests int64
+	for _, m := range data.ScopeMetrics[0].Metrics {
+		switch m.Name {
+		case "unsent.emails.sent":
+			for _, point := range m.Data.(metricdata.Sum[int64]).DataPoints {
+				domain, _ := point.Attributes.Value(AttrDomain)
+				sent[domain.AsString()] = point.Value
+			}
+		case "unsent.client.requests":
+			for _, point := range m.Data.(metricdata.Sum[int64]).DataPoints {
+				requests += point.Value
+			}
+		}
+	}
+	if sent["a.com"] != 2 || sent["b.com"] != 1 {
+		t.Errorf("unexpected emails per domain: %v", sent)
+	}
+	if requests != 2 {
+		t.Errorf("expected 2 requests, got %d", requests)
+	}
+}
+
+func attributeMap(attrs []attribute.KeyValue) map[string]string {
+	values := make(map[string]string)
+	for _, attr := range attrs {
+		values[string(attr.Key)] = attr.Value.Emit()
+	}
+	return values
+}
+
+type failingMeterProvider struct{ noop.MeterProvider }
+
+func (failingMeterProvider) Meter(string, ...metric.MeterOption) metric.Meter { return failingMeter{} }
+
+type failingMeter struct{ noop.Meter }
+
+func (failingMeter) Int64Counter(string, ...metric.Int64CounterOption) (metric.Int64Counter, error) {
+	return nil, errors.New("counter rejected")
+}
+
+func TestInstrumentReturnsError(t *testing.T) {
+	if _, err := Instrument(Options{MeterProvider: failingMeterProvider{}}); err == nil || err.Error() != "counter rejected" {
+		t.Errorf("expected the instrument error, got %v", err)
+	}
+}
@@ -0,0 +1,352 @@
+// Package unsentprom exposes Unsent account analytics as Prometheus metrics.
+// It lives in its own module so the core SDK does not depend on the
+// Prometheus client.
+//
+//	collector := unsentprom.NewCollector(client, unsentprom.Options{})
+//	prometheus.MustRegister(collector)
+//
+// The collector scrapes the analytics endpoints at most once per CacheTTL,
+// however often Prometheus scrapes it. Refreshes run in the background, so a
+// Prometheus scrape is served from the last snapshot and never waits on the
+// API, except for the very first one. Series with an empty domain label
+// cover the whole account.
+package unsentprom
+
+import (
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/souravsspace/unsent-go/pkg/unsent"
+)
+
+// Options configures a Collector
+type Options struct {
+	// CacheTTL is how long scraped values are reused, defaults to 5 minutes
+	CacheTTL time.Duration
+	// Window is the period counts and rates cover, defaults to 24 hours.
+	// Per-domain counts are read in whole days.
+	Window time.Duration
+	// Domains limits per-domain series to these domain names, defaults to every domain
+	Domains []string
+	// Namespace prefixes metric names, defaults to "unsent"
+	Namespace string
+	// RefreshTimeout bounds a refresh, defaults to 30 seconds. A refresh that
+	// takes longer counts as failed and its late result is discarded.
+	RefreshTimeout time.Duration
+	// Concurrency limits parallel requests of a refresh, defaults to 4
+	Concurrency int
+}
+
+// Collector is a prometheus.Collector for account and per-domain deliverability
+type Collector struct {
+	client *unsent.Client
+	opts   Options
+
+	emails      *prometheus.Desc
+	allTime     *prometheus.Desc
+	rates       map[string]*prometheus.Desc
+	reputation  *prometheus.Desc
+	up          *prometheus.Desc
+	lastRefresh *prometheus.Desc
+	age         *prometheus.Desc
+	errors      *prometheus.CounterVec
+
+	mu          sync.Mutex
+	snapshot    []prometheus.Metric
+	refreshedAt time.Time
+	succeededAt time.Time
+	healthy     bool
+	// refreshing is closed when the running refresh finishes, nil when idle
+	refreshing chan struct{}
+	// settled is closed when the running refresh finishes or times out
+	settled chan struct{}
+	now     func() time.Time
+}
+
+// rateNames are the rate gauges, in the order of unsent.Metrics
+var rateNames = []string{"delivery", "open", "click", "bounce", "complaint"}
+
+// emailStatuses are the status label values of the email count gauges
+var emailStatuses = []string{"total", "sent", "delivered", "opened", "clicked", "bounced", "complained", "failed"}
+
+// NewCollector creates a collector; register it with a prometheus.Registerer
+func NewCollector(client *unsent.Client, opts Options) *Collector {
+	if opts.CacheTTL <= 0 {
+		opts.CacheTTL = 5 * time.Minute
+	}
+	if opts.Window <= 0 {
+		opts.Window = 24 * time.Hour
+	}
+	if opts.Namespace == "" {
+		opts.Namespace = "unsent"
+	}
+	if opts.RefreshTimeout <= 0 {
+		opts.RefreshTimeout = 30 * time.Second
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 4
+	}
+	ns := opts.Namespace
+	c := &Collector{
+		client: client,
+		opts:   opts,
+		emails: prometheus.NewDesc(ns+"_emails", "Emails by status within the window.",
+			[]string{"domain", "status"}, nil),
+		allTime: prometheus.NewDesc(ns+"_emails_all_time", "Emails by status since the account was created.",
+			[]string{"status"}, nil),
+		rates: make(map[string]*prometheus.Desc),
+		reputation: prometheus.NewDesc(ns+"_reputation_score", "Sender reputation score of a domain.",
+			[]string{"domain"}, nil),
+		up: prometheus.NewDesc(ns+"_up", "Whether the last refresh of every endpoint succeeded.", nil, nil),
+		lastRefresh: prometheus.NewDesc(ns+"_last_refresh_timestamp_seconds",
+			"Unix time of the last fully successful refresh.", nil, nil),
+		age: prometheus.NewDesc(ns+"_snapshot_age_seconds",
+			"Seconds since the exported values were refreshed successfully.", nil, nil),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: ns,
+			Name:      "refresh_errors_total",
+			Help:      "Failed analytics requests by endpoint.",
+		}, []string{"endpoint"}),
+		now: time.Now,
+	}
+	for _, name := range rateNames {
+		c.rates[name] = prometheus.NewDesc(ns+"_"+name+"_rate", "The "+name+" rate within the window, from 0 to 1.",
+			[]string{"domain"}, nil)
+	}
+	return c
+}
+
+// Describe implements prometheus.Collector
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.emails
+	ch <- c.allTime
+	for _, name := range rateNames {
+		ch <- c.rates[name]
+	}
+	ch <- c.reputation
+	ch <- c.up
+	ch <- c.lastRefresh
+	ch <- c.age
+	c.errors.Describe(ch)
+}
+
+// Collect implements prometheus.Collector. It starts a background refresh
+// when the cache has expired and exports the current snapshot; only the
+// first scrape waits for a refresh, up to RefreshTimeout.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.Lock()
+	now := c.now()
+	if c.refreshing == nil && now.Sub(c.refreshedAt) >= c.opts.CacheTTL {
+		c.refreshedAt = now
+		c.refreshing = make(chan struct{})
+		c.settled = make(chan struct{})
+		go c.refresh(now, c.refreshing, c.settled)
+	}
+	settled := c.settled
+	first := c.succeededAt.IsZero()
+	c.mu.Unlock()
+	if first && settled != nil {
+		<-settled
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, m := range c.snapshot {
+		ch <- m
+	}
+	up := 0.0
+	if c.healthy {
+		up = 1
+	}
+	ch <- prometheus.MustNewConstMetric(c.up, prometheus.GaugeValue, up)
+	if !c.succeededAt.IsZero() {
+		ch <- prometheus.MustNewConstMetric(c.lastRefresh, prometheus.GaugeValue, float64(c.succeededAt.Unix()))
+		ch <- prometheus.MustNewConstMetric(c.age, prometheus.GaugeValue, c.now().Sub(c.succeededAt).Seconds())
+	}
+	c.errors.Collect(ch)
+}
+
+// refresh scrapes every endpoint, closes settled once it finishes or times
+// out and done once it finishes. When any request fails or the refresh
+// exceeds RefreshTimeout, the previous snapshot is kept and up reports 0
+// until a refresh succeeds.
+func (c *Collector) refresh(now time.Time, done, settled chan struct{}) {
+	defer close(done)
+	// both guarded by c.mu; whichever of finishing and timing out comes
+	// first closes settled
+	finished, timedOut := false, false
+	timer := time.AfterFunc(c.opts.RefreshTimeout, func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if finished {
+			return
+		}
+		timedOut = true
+		c.healthy = false
+		c.errors.WithLabelValues("timeout").Inc()
+		close(settled)
+	})
+	metrics, failed := c.scrape(now)
+	timer.Stop()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	finished = true
+	c.refreshing = nil
+	c.settled = nil
+	if timedOut {
+		return
+	}
+	close(settled)
+	c.healthy = !failed
+	if !failed {
+		c.snapshot = metrics
+		c.succeededAt = now
+	}
+}
+
+// scrape reads every endpoint, up to Concurrency requests at a time, and
+// reports whether any request failed
+func (c *Collector) scrape(now time.Time) ([]prometheus.Metric, bool) {
+	var mu sync.Mutex
+	var metrics []prometheus.Metric
+	failed := false
+	fail := func(endpoint string) {
+		c.errors.WithLabelValues(endpoint).Inc()
+		mu.Lock()
+		failed = true
+		mu.Unlock()
+	}
+	gauge := func(desc *prometheus.Desc, value float64, labels ...string) {
+		metric := prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, value, labels...)
+		mu.Lock()
+		metrics = append(metrics, metric)
+		mu.Unlock()
+	}
+
+	start := now.Add(-c.opts.Window).UTC()
+	tasks := []func(){
+		func() {
+			if stats, err := c.client.Stats.Get(unsent.GetStatsParams{StartDate: &start}); err != nil {
+				fail("stats")
+			} else {
+				s := stats.Data
+				c.countGauges(gauge, "", [8]int{s.Total, s.Sent, s.Delivered, s.Opened, s.Clicked, s.Bounced, s.Complained, s.Failed})
+			}
+		},
+		func() {
+			if analytics, err := c.client.Analytics.Get(); err != nil {
+				fail("analytics")
+			} else {
+				a := *analytics
+				for i, value := range []int{a.Total, a.Sent, a.Delivered, a.Opened, a.Clicked, a.Bounced, a.Complained, a.Failed} {
+					gauge(c.allTime, float64(value), emailStatuses[i])
+				}
+			}
+		},
+		func() {
+			period := metricsPeriod(c.opts.Window)
+			if resp, err := c.client.Metrics.Get(unsent.GetMetricsParams{Period: &period}); err != nil {
+				fail("metrics")
+			} else {
+				m := resp.Data
+				for i, value := range []float64{m.DeliveryRate, m.OpenRate, m.ClickRate, m.BounceRate, m.ComplaintRate} {
+					gauge(c.rates[rateNames[i]], value, "")
+				}
+			}
+		},
+	}
+
+	domains, ok := c.domainNames()
+	if !ok {
+		fail("domains")
+	}
+	days := strconv.Itoa(int(math.Ceil(c.opts.Window.Hours() / 24)))
+	for _, domain := range domains {
+		tasks = append(tasks, func() {
+			series, err := c.client.Analytics.GetTimeSeries(unsent.GetTimeSeriesParams{Days: &days, Domain: &domain})
+			if err != nil {
+				fail("time_series")
+				return
+			}
+			var counts [8]int
+			for _, day := range series.Data {
+				for i, value := range []int{day.Total, day.Sent, day.Delivered, day.Opened, day.Clicked, day.Bounced, day.Complained, day.Failed} {
+					counts[i] += value
+				}
+			}
+			c.countGauges(gauge, domain, counts)
+			c.rateGauges(gauge, domain, counts)
+		}, func() {
+			if rep, err := c.client.Analytics.GetReputation(unsent.GetReputationParams{Domain: &domain}); err != nil {
+				fail("reputation")
+			} else {
+				gauge(c.reputation, float64(rep.Reputation), domain)
+			}
+		})
+	}
+
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, c.opts.Concurrency)
+	for _, task := range tasks {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+			task()
+		}()
+	}
+	wg.Wait()
+	return metrics, failed
+}
+
+func (c *Collector) countGauges(gauge func(*prometheus.Desc, float64, ...string), domain string, counts [8]int) {
+	for i, value := range counts {
+		gauge(c.emails, float64(value), domain, emailStatuses[i])
+	}
+}
+
+// rateGauges derives a domain's rates from its counts: delivery and bounce
+// against sent, open, click and complaint against delivered
+func (c *Collector) rateGauges(gauge func(*prometheus.Desc, float64, ...string), domain string, counts [8]int) {
+	sent, delivered := float64(counts[1]), float64(counts[2])
+	if sent > 0 {
+		gauge(c.rates["delivery"], delivered/sent, domain)
+		gauge(c.rates["bounce"], float64(counts[5])/sent, domain)
+	}
+	if delivered > 0 {
+		gauge(c.rates["open"], float64(counts[3])/delivered, domain)
+		gauge(c.rates["click"], float64(counts[4])/delivered, domain)
+		gauge(c.rates["complaint"], float64(counts[6])/delivered, domain)
+	}
+}
+
+func (c *Collector) domainNames() ([]string, bool) {
+	if len(c.opts.Domains) > 0 {
+		return c.opts.Domains, true
+	}
+	domains, err := c.client.Domains.List()
+	if err != nil {
+		return nil, false
+	}
+	names := make([]string, 0, len(*domains))
+	for _, domain := range *domains {
+		names = append(names, domain.Domain)
+	}
+	return names, true
+}
+
+// metricsPeriod picks the smallest Metrics.Get period that covers window
+func metricsPeriod(window time.Duration) unsent.GetMetricsParamsPeriod {
+	switch {
+	case window <= 24*time.Hour:
+		return unsent.GetMetricsParamsPeriodDay
+	case window <= 7*24*time.Hour:
+		return unsent.GetMetricsParamsPeriodWeek
+	}
+	return unsent.GetMetricsParamsPeriodMonth
+}
@@ -0,0 +1,201 @@
+package unsentprom
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/souravsspace/unsent-go/pkg/unsent"
+)
+
+func newAnalyticsServer(t *testing.T, requests *int32, failMetrics *atomic.Bool) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(requests, 1)
+		query := r.URL.Query()
+		switch r.URL.Path {
+		case "/v1/stats":
+			if query.Get("startDate") == "" {
+				t.Errorf("expected a startDate")
+			}
+			w.Write([]byte(`{"data": {"total": 1000, "sent": 1000, "delivered": 950, "bounced": 30, "complained": 1}}`))
+		case "/v1/analytics":
+			w.Write([]byte(`{"total": 50000, "sent": 49000, "delivered": 48000}`))
+		case "/v1/metrics":
+			if failMetrics.Load() {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			if query.Get("period") != "day" {
+				t.Errorf("expected period=day, got %s", r.URL.RawQuery)
+			}
+			w.Write([]byte(`{"data": {"deliveryRate": 0.95, "openRate": 0.3, "clickRate": 0.05, "bounceRate": 0.03, "complaintRate": 0.001}}`))
+		case "/v1/domains":
+			w.Write([]byte(`[{"id": "d1", "name": "a.com"}, {"id": "d2", "name": "b.com"}]`))
+		case "/v1/analytics/time-series":
+			if query.Get("days") != "1" {
+				t.Errorf("expected days=1, got %s", r.URL.RawQuery)
+			}
+			if query.Get("domain") == "a.com" {
+				w.Write([]byte(`{"data": [{"date": "2025-01-15", "sent": 800, "delivered": 760, "opened": 190, "bounced": 40, "complained": 2}]}`))
+			} else {
+				w.Write([]byte(`{"data": []}`))
+			}
+		case "/v1/analytics/reputation":
+			w.Write([]byte(`{"domain": "` + query.Get("domain") + `", "reputation": 87}`))
+		default:
+			t.Errorf("unexpected request %s", r.URL.Path)
+		}
+	}))
+}
+
+func gather(t *testing.T, registry *prometheus.Registry) map[string][]*dto.Metric {
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("gather: %v", err)
+	}
+	metrics := make(map[string][]*dto.Metric)
+	for _, family := range families {
+		metrics[family.GetName()] = family.GetMetric()
+	}
+	return metrics
+}
+
+func gaugeValue(metrics []*dto.Metric, labels map[string]string) (float64, bool) {
+	for _, m := range metrics {
+		match := true
+		for _, pair := range m.GetLabel() {
+			if want, ok := labels[pair.GetName()]; ok && want != pair.GetValue() {
+				match = false
+			}
+		}
+		if match {
+			return m.GetGauge().GetValue(), true
+		}
+	}
+	return 0, false
+}
+
+// waitRefresh blocks until the collector's background refresh, if any, is done
+func waitRefresh(c *Collector) {
+	c.mu.Lock()
+	refreshing := c.refreshing
+	c.mu.Unlock()
+	if refreshing != nil {
+		<-refreshing
+	}
+}
+
+func TestCollector(t *testing.T) {
+	var requests int32
+	var failMetrics atomic.Bool
+	server := newAnalyticsServer(t, &requests, &failMetrics)
+	defer server.Close()
+	client, _ := unsent.NewClient("key", unsent.WithBaseURL(server.URL))
+
+	collector := NewCollector(client, Options{CacheTTL: time.Minute})
+	now := time.Date(2025, 1, 15, 12, 0, 0, 0, time.UTC)
+	collector.now = func() time.Time { return now }
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(collector)
+
+	metrics := gather(t, registry)
+	checks := []struct {
+		name   string
+		labels map[string]string
+		want   float64
+	}{
+		{"unsent_emails", map[string]string{"domain": "", "status": "bounced"}, 30},
+		{"unsent_emails", map[string]string{"domain": "a.com", "status": "sent"}, 800},
+		{"unsent_emails_all_time", map[string]string{"status": "sent"}, 49000},
+		{"unsent_delivery_rate", map[string]string{"domain": ""}, 0.95},
+		{"unsent_delivery_rate", map[string]string{"domain": "a.com"}, 0.95},
+		{"unsent_bounce_rate", map[string]string{"domain": "a.com"}, 0.05},
+		{"unsent_open_rate", map[string]string{"domain": "a.com"}, 0.25},
+		{"unsent_reputation_score", map[string]string{"domain": "b.com"}, 87},
+		{"unsent_up", nil, 1},
+	}
+	for _, c := range checks {
+		if got, ok := gaugeValue(metrics[c.name], c.labels); !ok || got != c.want {
+			t.Errorf("%s%v: expected %v, got %v (found %v)", c.name, c.labels, c.want, got, ok)
+		}
+	}
+	if _, ok := gaugeValue(metrics["unsent_delivery_rate"], map[string]string{"domain": "b.com"}); ok {
+		t.Error("expected no rate for a domain without sends")
+	}
+
+	// scrapes within the TTL are served from the cache
+	scraped := atomic.LoadInt32(&requests)
+	gather(t, registry)
+	if atomic.LoadInt32(&requests) != scraped {
+		t.Errorf("expected cached values, got %d more requests", atomic.LoadInt32(&requests)-scraped)
+	}
+
+	// a failed refresh keeps the previous values and reports down
+	failMetrics.Store(true)
+	now = now.Add(2 * time.Minute)
+	gather(t, registry)
+	waitRefresh(collector)
+	metrics = gather(t, registry)
+	if got, _ := gaugeValue(metrics["unsent_up"], nil); got != 0 {
+		t.Errorf("expected unsent_up 0, got %v", got)
+	}
+	if got, _ := gaugeValue(metrics["unsent_delivery_rate"], map[string]string{"domain": ""}); got != 0.95 {
+		t.Errorf("expected the previous rate, got %v", got)
+	}
+	if errors := metrics["unsent_refresh_errors_total"]; len(errors) != 1 || errors[0].GetCounter().GetValue() != 1 {
+		t.Errorf("expected one metrics error, got %v", errors)
+	}
+	if got, _ := gaugeValue(metrics["unsent_snapshot_age_seconds"], nil); got != 120 {
+		t.Errorf("expected a snapshot age of 120s, got %v", got)
+	}
+}
+
+func TestCollector_RefreshTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/metrics":
+			<-release
+			w.Write([]byte(`{"data": {}}`))
+		case "/v1/analytics/time-series":
+			w.Write([]byte(`{"data": []}`))
+		default:
+			w.Write([]byte(`{"data": {}}`))
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+	client, _ := unsent.NewClient("key", unsent.WithBaseURL(server.URL))
+
+	collector := NewCollector(client, Options{Domains: []string{"a.com"}, RefreshTimeout: 50 * time.Millisecond})
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(collector)
+
+	// the first scrape waits for the refresh, but no longer than the timeout
+	start := time.Now()
+	metrics := gather(t, registry)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected the scrape to give up after the timeout, took %v", elapsed)
+	}
+	if got, _ := gaugeValue(metrics["unsent_up"], nil); got != 0 {
+		t.Errorf("expected unsent_up 0, got %v", got)
+	}
+	if got, ok := gaugeValue(metrics["unsent_emails"], nil); ok {
+		t.Errorf("expected no values before a refresh succeeded, got %v", got)
+	}
+	errors := metrics["unsent_refresh_errors_total"]
+	if len(errors) != 1 || errors[0].GetLabel()[0].GetValue() != "timeout" {
+		t.Errorf("expected a timeout error, got %v", errors)
+	}
+
+	// a scrape during the hung refresh is served right away
+	start = time.Now()
+	gather(t, registry)
+	if elapsed := time.Since(start); elapsed > 40*time.Millisecond {
+		t.Errorf("expected a scrape not to wait on a running refresh, took %v", elapsed)
+	}
+}